@@ -0,0 +1,374 @@
+package ast
+
+import (
+	"strings"
+	"temlang/tem/token"
+)
+
+// Position is the byte offset range of a node in the source
+type Position struct {
+	Start int
+	End   int
+}
+
+func (p Position) Pos() Position {
+	return p
+}
+
+type Node interface {
+	Pos() Position
+}
+
+// Decl is a top level declaration or a declaration in a record.
+type Decl interface {
+	Node
+	declNode()
+}
+
+type Expr interface {
+	Node
+	exprNode()
+}
+
+// Markup is an element, a text or an interpolation in the body of a templ.
+type Markup interface {
+	Node
+	markupNode()
+}
+
+type Ident struct {
+	Name string
+	// Obj is the object the ident denotes, nil until resolved.
+	Obj *Object
+	Position
+}
+
+// BasicLit is a string or a text block. Value is the literal as it appears
+// in the source.
+type BasicLit struct {
+	Kind  token.Kind
+	Value string
+	Position
+}
+
+type BadDecl struct {
+	Position
+}
+
+// PackageDecl declares the package of a namespace.
+//
+//	p :: package("name")
+type PackageDecl struct {
+	Names []*Ident
+	// Type is nil if the type of the declaration is inferred.
+	Type       *Ident
+	Directives []*Ident
+	Value      Expr
+	Annotations
+	Comments
+	Position
+}
+
+// ImportDecl declares an imported package.
+//
+//	i :: import("path")
+type ImportDecl struct {
+	Names      []*Ident
+	Type       *Ident
+	Directives []*Ident
+	Value      Expr
+	Annotations
+	Comments
+	Position
+}
+
+// UsingDecl declares names defined in another package or namespace.
+//
+//	A, B :: using(i)
+type UsingDecl struct {
+	Names      []*Ident
+	Type       *Ident
+	Directives []*Ident
+	Value      Expr
+	Annotations
+	Comments
+	Position
+}
+
+// TypeDecl declares a type derived from another type or a record.
+//
+//	T :: type(String)
+//	R :: record{ name: String }
+type TypeDecl struct {
+	Names      []*Ident
+	Type       *Ident
+	Directives []*Ident
+	Value      Expr
+	Annotations
+	Comments
+	Position
+}
+
+// TemplDecl declares a template.
+//
+//	T :: templ(t: T) { <p (t.name)/> }
+type TemplDecl struct {
+	Names      []*Ident
+	Type       *Ident
+	Directives []*Ident
+	Value      Expr
+	Annotations
+	Comments
+	Position
+}
+
+// Field is a record field, a templ parameter or a top level var.
+//
+//	name: String
+type Field struct {
+	Names []*Ident
+	Type  Expr
+	Annotations
+	Comments
+	Position
+}
+
+// Doc documents the declarations with the same names.
+//
+//	T : "documentation"
+type Doc struct {
+	Names []*Ident
+	Text  []*BasicLit
+	Comments
+	Position
+}
+
+// Lines returns the lines of the text of the doc without the quotes and
+// the --- markers. The blank lines around the text are dropped.
+func (d *Doc) Lines() []string {
+	var lines []string
+	for _, lit := range d.Text {
+		if lit.Kind == token.TextBlock {
+			lines = append(lines, token.TextBlockText(lit.Value))
+			continue
+		}
+		text, err := token.Unquote(lit.Value)
+		if err != nil {
+			text = lit.Value
+		}
+		lines = append(lines, strings.Split(text, "\n")...)
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Tag adds attributes to the declarations with the same names.
+//
+//	T : { key = "value" }
+type Tag struct {
+	Names []*Ident
+	Attrs []*Attr
+	Comments
+	Position
+}
+
+// Attr is an attribute of a tag or an element.
+type Attr struct {
+	Names []*Ident
+	Value Expr
+	Comments
+	Position
+}
+
+type BadExpr struct {
+	Position
+}
+
+type PackageExpr struct {
+	Name *BasicLit
+	Position
+}
+
+type ImportExpr struct {
+	Path *BasicLit
+	Position
+}
+
+type UsingExpr struct {
+	Target Expr
+	Position
+}
+
+type TypeExpr struct {
+	Target *Ident
+	Position
+}
+
+type RecordType struct {
+	// Fields are the fields, docs and tags in declaration order.
+	Fields []Decl
+	Position
+}
+
+type TemplLit struct {
+	Params []*Field
+	Body   []Markup
+	Position
+}
+
+// ListType is the type of a list of values of the element type.
+//
+//	[]User
+type ListType struct {
+	Elem Expr
+	Position
+}
+
+// SelectorExpr is a dotted name.
+//
+//	u.name
+type SelectorExpr struct {
+	X   Expr
+	Sel *Ident
+	Position
+}
+
+// CallExpr is a call to a helper in an interpolation.
+//
+//	upper(u.name)
+type CallExpr struct {
+	Fun  Expr
+	Args []Expr
+	Position
+}
+
+// ParenExpr is a parenthesized expression in an interpolation.
+//
+//	(u.name)
+type ParenExpr struct {
+	X Expr
+	Position
+}
+
+// Element is a template element.
+//
+//	<p class="x" text (interpolation)/>
+type Element struct {
+	Name     *Ident
+	Attrs    []*Attr
+	Children []Markup
+	Position
+}
+
+// IfElement renders its children if the condition is true, else the
+// children of its else element.
+//
+//	<if (u.admin) <p admin/>/>
+//	<else <p user/>/>
+type IfElement struct {
+	Cond     Expr
+	Children []Markup
+	// Else is nil if the if element is not followed by an else element.
+	Else *ElseElement
+	Position
+}
+
+// ElseElement is the alternative of an if element.
+type ElseElement struct {
+	Children []Markup
+	Position
+}
+
+// ForElement renders its children for each value of a list, the value and
+// its index are bound to Value and Index.
+//
+//	<for (i, f in u.friends) <p (i) (f.name)/>/>
+type ForElement struct {
+	// Index is nil if the index is not bound.
+	Index    *Ident
+	Value    *Ident
+	X        Expr
+	Children []Markup
+	Position
+}
+
+type Text struct {
+	Value string
+	Position
+}
+
+type Interp struct {
+	X Expr
+	Position
+}
+
+func (*BadDecl) declNode()     {}
+func (*PackageDecl) declNode() {}
+func (*ImportDecl) declNode()  {}
+func (*UsingDecl) declNode()   {}
+func (*TypeDecl) declNode()    {}
+func (*TemplDecl) declNode()   {}
+func (*Field) declNode()       {}
+func (*Doc) declNode()         {}
+func (*Tag) declNode()         {}
+
+func (*BadExpr) exprNode()      {}
+func (*Ident) exprNode()        {}
+func (*BasicLit) exprNode()     {}
+func (*PackageExpr) exprNode()  {}
+func (*ImportExpr) exprNode()   {}
+func (*UsingExpr) exprNode()    {}
+func (*TypeExpr) exprNode()     {}
+func (*RecordType) exprNode()   {}
+func (*TemplLit) exprNode()     {}
+func (*SelectorExpr) exprNode() {}
+func (*CallExpr) exprNode()     {}
+func (*ListType) exprNode()     {}
+func (*ParenExpr) exprNode()    {}
+
+func (*BadDecl) markupNode()    {}
+func (*Element) markupNode()    {}
+func (*IfElement) markupNode()  {}
+func (*ForElement) markupNode() {}
+func (*Text) markupNode()       {}
+func (*Interp) markupNode()     {}
+
+// Annotations are the docs and tags attached to a declaration or a field
+// with the same name.
+type Annotations struct {
+	docs []*Doc
+	tags []*Tag
+}
+
+// Doc returns the docs of the declaration in source order.
+func (a *Annotations) Doc() []*Doc {
+	return a.docs
+}
+
+// Tags returns the tags of the declaration in source order.
+func (a *Annotations) Tags() []*Tag {
+	return a.tags
+}
+
+func (a *Annotations) AddDoc(d *Doc) {
+	a.docs = append(a.docs, d)
+}
+
+func (a *Annotations) AddTag(t *Tag) {
+	a.tags = append(a.tags, t)
+}
+
+// Annotated is implemented by the declarations docs and tags are attached
+// to.
+type Annotated interface {
+	Decl
+	Doc() []*Doc
+	Tags() []*Tag
+	AddDoc(d *Doc)
+	AddTag(t *Tag)
+}
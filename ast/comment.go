@@ -0,0 +1,61 @@
+package ast
+
+import "strings"
+
+// Comment is a line comment.
+//
+//	// comment
+type Comment struct {
+	// Text is the comment as it appears in the source, // included.
+	Text string
+	Position
+}
+
+// CommentGroup is a sequence of comments with no blank line or other
+// token between them.
+type CommentGroup struct {
+	List []*Comment
+	Position
+}
+
+// Text returns the text of the comments without the comment markers, one
+// line per comment.
+func (g *CommentGroup) Text() string {
+	if g == nil {
+		return ""
+	}
+	lines := make([]string, 0, len(g.List))
+	for _, c := range g.List {
+		text := strings.TrimPrefix(c.Text, "//")
+		lines = append(lines, strings.TrimPrefix(text, " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Comments are the comment groups attached to a declaration, a field or
+// an attribute when the comments are parsed.
+type Comments struct {
+	// Leading is the group on the lines right before the node.
+	Leading *CommentGroup
+	// Trailing is the comment at the end of the last line of the node.
+	Trailing *CommentGroup
+}
+
+func (c *Comments) comments() *Comments {
+	return c
+}
+
+// Commented is implemented by the nodes comments are attached to.
+type Commented interface {
+	Node
+	comments() *Comments
+}
+
+// CommentsOf returns the comments attached to n or nil if comments cannot
+// be attached to n.
+func CommentsOf(n Node) *Comments {
+	if c, ok := n.(Commented); ok {
+		return c.comments()
+	}
+	return nil
+}
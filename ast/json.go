@@ -0,0 +1,739 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"temlang/tem/token"
+)
+
+// The JSON of a namespace is an object holding the file, the package
+// name, the line offsets, the declarations and the comment groups:
+//
+//	{"node": "Namespace", "file": "user.tem", "package": "", "lines": [0, 17],
+//	 "decls": [...], "comments": [...]}
+//
+// Each node is an object with its type in "node", its start and end as
+// offset, line and column in "start" and "end", and its fields with lower
+// case names:
+//
+//	{"node": "Ident", "name": "User",
+//	 "start": {"offset": 18, "line": 2, "col": 1},
+//	 "end": {"offset": 22, "line": 2, "col": 5}}
+//
+// The docs and tags of a declaration and the comments of a node are
+// written in full. They are the same nodes as the docs, the tags and the
+// comment groups of the namespace once decoded. The resolved objects are
+// left out.
+
+// jsonPos is a position in the JSON of a namespace.
+type jsonPos struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Col    int `json:"col"`
+}
+
+type jsonObject map[string]any
+
+// MarshalJSON returns the JSON of the namespace.
+func (n *Namespace) MarshalJSON() ([]byte, error) {
+	e := jsonEncoder{ns: n}
+	lines := n.lines
+	if lines == nil {
+		lines = []int{}
+	}
+	comments := make([]any, 0, len(n.comments))
+	for _, g := range n.comments {
+		comments = append(comments, e.group(g))
+	}
+	return json.Marshal(jsonObject{
+		"node":     "Namespace",
+		"file":     n.file,
+		"name":     n.name,
+		"package":  n.pkg,
+		"lines":    lines,
+		"decls":    e.decls(n.decl),
+		"comments": comments,
+	})
+}
+
+type jsonEncoder struct {
+	ns *Namespace
+}
+
+func (e *jsonEncoder) pos(offset int) jsonPos {
+	p := e.ns.LineCol(offset)
+	return jsonPos{Offset: offset, Line: p.Line, Col: p.Col}
+}
+
+func (e *jsonEncoder) object(kind string, pos Position, fields jsonObject) jsonObject {
+	fields["node"] = kind
+	fields["start"] = e.pos(pos.Start)
+	fields["end"] = e.pos(pos.End)
+	return fields
+}
+
+func (e *jsonEncoder) decls(ds []Decl) []any {
+	list := make([]any, 0, len(ds))
+	for _, d := range ds {
+		list = append(list, e.node(d))
+	}
+	return list
+}
+
+func (e *jsonEncoder) idents(ids []*Ident) []any {
+	list := make([]any, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, e.ident(id))
+	}
+	return list
+}
+
+func (e *jsonEncoder) ident(id *Ident) any {
+	if id == nil {
+		return nil
+	}
+	return e.object("Ident", id.Position, jsonObject{"name": id.Name})
+}
+
+func (e *jsonEncoder) lit(lit *BasicLit) any {
+	if lit == nil {
+		return nil
+	}
+	return e.object("BasicLit", lit.Position, jsonObject{
+		"kind":  lit.Kind.String(),
+		"value": lit.Value,
+	})
+}
+
+func (e *jsonEncoder) group(g *CommentGroup) any {
+	if g == nil {
+		return nil
+	}
+	list := make([]any, 0, len(g.List))
+	for _, c := range g.List {
+		list = append(list, e.object("Comment", c.Position, jsonObject{"text": c.Text}))
+	}
+	return e.object("CommentGroup", g.Position, jsonObject{"list": list})
+}
+
+func (e *jsonEncoder) attrs(attrs []*Attr) []any {
+	list := make([]any, 0, len(attrs))
+	for _, a := range attrs {
+		list = append(list, e.node(a))
+	}
+	return list
+}
+
+func (e *jsonEncoder) markups(ms []Markup) []any {
+	list := make([]any, 0, len(ms))
+	for _, m := range ms {
+		list = append(list, e.node(m))
+	}
+	return list
+}
+
+// decl returns the fields shared by the declarations.
+func (e *jsonEncoder) decl(names []*Ident, typ *Ident, directives []*Ident, value Expr, a *Annotations, c *Comments) jsonObject {
+	return e.annotated(jsonObject{
+		"names":      e.idents(names),
+		"type":       e.ident(typ),
+		"directives": e.idents(directives),
+		"value":      e.node(value),
+	}, a, c)
+}
+
+// annotated adds the docs, the tags and the comments of a declaration to
+// obj.
+func (e *jsonEncoder) annotated(obj jsonObject, a *Annotations, c *Comments) jsonObject {
+	docs := make([]any, 0, len(a.docs))
+	for _, d := range a.docs {
+		docs = append(docs, e.node(d))
+	}
+	tags := make([]any, 0, len(a.tags))
+	for _, t := range a.tags {
+		tags = append(tags, e.node(t))
+	}
+	obj["docs"] = docs
+	obj["tags"] = tags
+	return e.comments(obj, c)
+}
+
+func (e *jsonEncoder) comments(obj jsonObject, c *Comments) jsonObject {
+	obj["leading"] = e.group(c.Leading)
+	obj["trailing"] = e.group(c.Trailing)
+	return obj
+}
+
+func (e *jsonEncoder) node(n Node) any {
+	switch n := n.(type) {
+	case nil:
+		return nil
+	case *Ident:
+		return e.ident(n)
+	case *BasicLit:
+		return e.lit(n)
+	case *BadDecl:
+		return e.object("BadDecl", n.Position, jsonObject{})
+	case *BadExpr:
+		return e.object("BadExpr", n.Position, jsonObject{})
+	case *PackageDecl:
+		obj := e.decl(n.Names, n.Type, n.Directives, n.Value, &n.Annotations, &n.Comments)
+		return e.object("PackageDecl", n.Position, obj)
+	case *ImportDecl:
+		obj := e.decl(n.Names, n.Type, n.Directives, n.Value, &n.Annotations, &n.Comments)
+		return e.object("ImportDecl", n.Position, obj)
+	case *UsingDecl:
+		obj := e.decl(n.Names, n.Type, n.Directives, n.Value, &n.Annotations, &n.Comments)
+		return e.object("UsingDecl", n.Position, obj)
+	case *TypeDecl:
+		obj := e.decl(n.Names, n.Type, n.Directives, n.Value, &n.Annotations, &n.Comments)
+		return e.object("TypeDecl", n.Position, obj)
+	case *TemplDecl:
+		obj := e.decl(n.Names, n.Type, n.Directives, n.Value, &n.Annotations, &n.Comments)
+		return e.object("TemplDecl", n.Position, obj)
+	case *Field:
+		obj := e.annotated(jsonObject{"names": e.idents(n.Names), "type": e.node(n.Type)}, &n.Annotations, &n.Comments)
+		return e.object("Field", n.Position, obj)
+	case *Doc:
+		text := make([]any, 0, len(n.Text))
+		for _, lit := range n.Text {
+			text = append(text, e.lit(lit))
+		}
+		obj := e.comments(jsonObject{"names": e.idents(n.Names), "text": text}, &n.Comments)
+		return e.object("Doc", n.Position, obj)
+	case *Tag:
+		obj := e.comments(jsonObject{"names": e.idents(n.Names), "attrs": e.attrs(n.Attrs)}, &n.Comments)
+		return e.object("Tag", n.Position, obj)
+	case *Attr:
+		obj := e.comments(jsonObject{"names": e.idents(n.Names), "value": e.node(n.Value)}, &n.Comments)
+		return e.object("Attr", n.Position, obj)
+	case *PackageExpr:
+		return e.object("PackageExpr", n.Position, jsonObject{"name": e.lit(n.Name)})
+	case *ImportExpr:
+		return e.object("ImportExpr", n.Position, jsonObject{"path": e.lit(n.Path)})
+	case *UsingExpr:
+		return e.object("UsingExpr", n.Position, jsonObject{"target": e.node(n.Target)})
+	case *TypeExpr:
+		return e.object("TypeExpr", n.Position, jsonObject{"target": e.ident(n.Target)})
+	case *RecordType:
+		return e.object("RecordType", n.Position, jsonObject{"fields": e.decls(n.Fields)})
+	case *TemplLit:
+		params := make([]any, 0, len(n.Params))
+		for _, p := range n.Params {
+			params = append(params, e.node(p))
+		}
+		return e.object("TemplLit", n.Position, jsonObject{"params": params, "body": e.markups(n.Body)})
+	case *SelectorExpr:
+		return e.object("SelectorExpr", n.Position, jsonObject{"x": e.node(n.X), "sel": e.ident(n.Sel)})
+	case *ListType:
+		return e.object("ListType", n.Position, jsonObject{"elem": e.node(n.Elem)})
+	case *CallExpr:
+		args := make([]any, 0, len(n.Args))
+		for _, a := range n.Args {
+			args = append(args, e.node(a))
+		}
+		return e.object("CallExpr", n.Position, jsonObject{"fun": e.node(n.Fun), "args": args})
+	case *ParenExpr:
+		return e.object("ParenExpr", n.Position, jsonObject{"x": e.node(n.X)})
+	case *Element:
+		return e.object("Element", n.Position, jsonObject{
+			"name":     e.ident(n.Name),
+			"attrs":    e.attrs(n.Attrs),
+			"children": e.markups(n.Children),
+		})
+	case *Text:
+		return e.object("Text", n.Position, jsonObject{"value": n.Value})
+	case *Interp:
+		return e.object("Interp", n.Position, jsonObject{"x": e.node(n.X)})
+	case *IfElement:
+		obj := jsonObject{"cond": e.node(n.Cond), "children": e.markups(n.Children)}
+		if n.Else != nil {
+			obj["else"] = e.node(n.Else)
+		}
+		return e.object("IfElement", n.Position, obj)
+	case *ElseElement:
+		return e.object("ElseElement", n.Position, jsonObject{"children": e.markups(n.Children)})
+	case *ForElement:
+		obj := jsonObject{"value": e.ident(n.Value), "x": e.node(n.X), "children": e.markups(n.Children)}
+		if n.Index != nil {
+			obj["index"] = e.ident(n.Index)
+		}
+		return e.object("ForElement", n.Position, obj)
+	default:
+		panic(fmt.Sprintf("unreachable: %T", n))
+	}
+}
+
+// UnmarshalJSON sets the namespace to the namespace of the JSON written by
+// MarshalJSON. The namespace is not resolved.
+func (n *Namespace) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Node     string            `json:"node"`
+		File     string            `json:"file"`
+		Name     string            `json:"name"`
+		Package  string            `json:"package"`
+		Lines    []int             `json:"lines"`
+		Decls    []json.RawMessage `json:"decls"`
+		Comments []json.RawMessage `json:"comments"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Node != "Namespace" {
+		return fmt.Errorf("ast: expected a Namespace got %q", v.Node)
+	}
+
+	d := jsonDecoder{}
+	*n = Namespace{file: v.File, name: v.Name, pkg: v.Package, lines: v.Lines}
+	for _, raw := range v.Decls {
+		n.decl = append(n.decl, d.decl(raw))
+	}
+	for _, raw := range v.Comments {
+		if g := d.group(raw); g != nil {
+			n.comments = append(n.comments, g)
+		}
+	}
+	if d.err != nil {
+		return d.err
+	}
+	relink(n)
+	return nil
+}
+
+// jsonDecoder decodes the nodes of a namespace. The first error is kept in
+// err, the nodes decoded after an error are nil.
+type jsonDecoder struct {
+	err error
+}
+
+func (d *jsonDecoder) errorf(format string, args ...any) {
+	if d.err == nil {
+		d.err = fmt.Errorf("ast: "+format, args...)
+	}
+}
+
+// object decodes the fields of a node and returns its type and position.
+// A null node has no type.
+func (d *jsonDecoder) object(raw json.RawMessage) (string, map[string]json.RawMessage, Position) {
+	var obj map[string]json.RawMessage
+	if d.err != nil || len(raw) == 0 {
+		return "", nil, Position{}
+	}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		d.errorf("%s", err)
+		return "", nil, Position{}
+	}
+	if obj == nil {
+		return "", nil, Position{}
+	}
+	var kind string
+	var start, end jsonPos
+	d.value(obj["node"], &kind)
+	d.value(obj["start"], &start)
+	d.value(obj["end"], &end)
+	return kind, obj, Position{Start: start.Offset, End: end.Offset}
+}
+
+func (d *jsonDecoder) value(raw json.RawMessage, v any) {
+	if d.err != nil || len(raw) == 0 {
+		return
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		d.errorf("%s", err)
+	}
+}
+
+func (d *jsonDecoder) list(raw json.RawMessage) []json.RawMessage {
+	var list []json.RawMessage
+	d.value(raw, &list)
+	return list
+}
+
+func (d *jsonDecoder) expect(kind, want string) bool {
+	if kind != want {
+		d.errorf("expected %s got %q", want, kind)
+		return false
+	}
+	return true
+}
+
+func (d *jsonDecoder) ident(raw json.RawMessage) *Ident {
+	kind, obj, pos := d.object(raw)
+	if obj == nil || !d.expect(kind, "Ident") {
+		return nil
+	}
+	id := &Ident{Position: pos}
+	d.value(obj["name"], &id.Name)
+	return id
+}
+
+func (d *jsonDecoder) idents(raw json.RawMessage) []*Ident {
+	list := d.list(raw)
+	ids := make([]*Ident, 0, len(list))
+	for _, r := range list {
+		ids = append(ids, d.ident(r))
+	}
+	return ids
+}
+
+func (d *jsonDecoder) lit(raw json.RawMessage) *BasicLit {
+	kind, obj, pos := d.object(raw)
+	if obj == nil || !d.expect(kind, "BasicLit") {
+		return nil
+	}
+	lit := &BasicLit{Position: pos}
+	var k string
+	d.value(obj["kind"], &k)
+	d.value(obj["value"], &lit.Value)
+	lit.Kind = litKind(k)
+	if lit.Kind == token.Invalid {
+		d.errorf("invalid literal kind %q", k)
+	}
+	return lit
+}
+
+// litKind returns the literal kind with the name s or token.Invalid.
+func litKind(s string) token.Kind {
+	for k := token.LiteralBegin + 1; k < token.LiteralEnd; k++ {
+		if k.String() == s {
+			return k
+		}
+	}
+	return token.Invalid
+}
+
+func (d *jsonDecoder) group(raw json.RawMessage) *CommentGroup {
+	kind, obj, pos := d.object(raw)
+	if obj == nil || !d.expect(kind, "CommentGroup") {
+		return nil
+	}
+	g := &CommentGroup{Position: pos}
+	for _, r := range d.list(obj["list"]) {
+		kind, obj, pos := d.object(r)
+		if obj == nil || !d.expect(kind, "Comment") {
+			return nil
+		}
+		c := &Comment{Position: pos}
+		d.value(obj["text"], &c.Text)
+		g.List = append(g.List, c)
+	}
+	return g
+}
+
+func (d *jsonDecoder) comments(obj map[string]json.RawMessage) Comments {
+	return Comments{
+		Leading:  d.group(obj["leading"]),
+		Trailing: d.group(obj["trailing"]),
+	}
+}
+
+func (d *jsonDecoder) annotations(obj map[string]json.RawMessage) Annotations {
+	var a Annotations
+	for _, r := range d.list(obj["docs"]) {
+		if doc, ok := d.node(r).(*Doc); ok {
+			a.docs = append(a.docs, doc)
+		}
+	}
+	for _, r := range d.list(obj["tags"]) {
+		if tag, ok := d.node(r).(*Tag); ok {
+			a.tags = append(a.tags, tag)
+		}
+	}
+	return a
+}
+
+func (d *jsonDecoder) attrs(raw json.RawMessage) []*Attr {
+	list := d.list(raw)
+	attrs := make([]*Attr, 0, len(list))
+	for _, r := range list {
+		if a, ok := d.node(r).(*Attr); ok {
+			attrs = append(attrs, a)
+		} else {
+			d.errorf("expected Attr")
+		}
+	}
+	return attrs
+}
+
+func (d *jsonDecoder) decl(raw json.RawMessage) Decl {
+	decl, ok := d.node(raw).(Decl)
+	if !ok {
+		d.errorf("expected a declaration")
+	}
+	return decl
+}
+
+func (d *jsonDecoder) expr(raw json.RawMessage) Expr {
+	n := d.node(raw)
+	if n == nil {
+		return nil
+	}
+	e, ok := n.(Expr)
+	if !ok {
+		d.errorf("expected an expression got %T", n)
+	}
+	return e
+}
+
+func (d *jsonDecoder) markups(raw json.RawMessage) []Markup {
+	list := d.list(raw)
+	ms := make([]Markup, 0, len(list))
+	for _, r := range list {
+		m, ok := d.node(r).(Markup)
+		if !ok {
+			d.errorf("expected a markup")
+			continue
+		}
+		ms = append(ms, m)
+	}
+	return ms
+}
+
+func (d *jsonDecoder) node(raw json.RawMessage) Node {
+	kind, obj, pos := d.object(raw)
+	if obj == nil {
+		return nil
+	}
+	switch kind {
+	case "Ident":
+		return d.ident(raw)
+	case "BasicLit":
+		return d.lit(raw)
+	case "BadDecl":
+		return &BadDecl{Position: pos}
+	case "BadExpr":
+		return &BadExpr{Position: pos}
+	case "PackageDecl":
+		return &PackageDecl{
+			Names:       d.idents(obj["names"]),
+			Type:        d.ident(obj["type"]),
+			Directives:  d.idents(obj["directives"]),
+			Value:       d.expr(obj["value"]),
+			Annotations: d.annotations(obj),
+			Comments:    d.comments(obj),
+			Position:    pos,
+		}
+	case "ImportDecl":
+		return &ImportDecl{
+			Names:       d.idents(obj["names"]),
+			Type:        d.ident(obj["type"]),
+			Directives:  d.idents(obj["directives"]),
+			Value:       d.expr(obj["value"]),
+			Annotations: d.annotations(obj),
+			Comments:    d.comments(obj),
+			Position:    pos,
+		}
+	case "UsingDecl":
+		return &UsingDecl{
+			Names:       d.idents(obj["names"]),
+			Type:        d.ident(obj["type"]),
+			Directives:  d.idents(obj["directives"]),
+			Value:       d.expr(obj["value"]),
+			Annotations: d.annotations(obj),
+			Comments:    d.comments(obj),
+			Position:    pos,
+		}
+	case "TypeDecl":
+		return &TypeDecl{
+			Names:       d.idents(obj["names"]),
+			Type:        d.ident(obj["type"]),
+			Directives:  d.idents(obj["directives"]),
+			Value:       d.expr(obj["value"]),
+			Annotations: d.annotations(obj),
+			Comments:    d.comments(obj),
+			Position:    pos,
+		}
+	case "TemplDecl":
+		return &TemplDecl{
+			Names:       d.idents(obj["names"]),
+			Type:        d.ident(obj["type"]),
+			Directives:  d.idents(obj["directives"]),
+			Value:       d.expr(obj["value"]),
+			Annotations: d.annotations(obj),
+			Comments:    d.comments(obj),
+			Position:    pos,
+		}
+	case "Field":
+		return &Field{
+			Names:       d.idents(obj["names"]),
+			Type:        d.expr(obj["type"]),
+			Annotations: d.annotations(obj),
+			Comments:    d.comments(obj),
+			Position:    pos,
+		}
+	case "Doc":
+		text := d.list(obj["text"])
+		doc := &Doc{
+			Names:    d.idents(obj["names"]),
+			Text:     make([]*BasicLit, 0, len(text)),
+			Comments: d.comments(obj),
+			Position: pos,
+		}
+		for _, r := range text {
+			doc.Text = append(doc.Text, d.lit(r))
+		}
+		return doc
+	case "Tag":
+		return &Tag{
+			Names:    d.idents(obj["names"]),
+			Attrs:    d.attrs(obj["attrs"]),
+			Comments: d.comments(obj),
+			Position: pos,
+		}
+	case "Attr":
+		return &Attr{
+			Names:    d.idents(obj["names"]),
+			Value:    d.expr(obj["value"]),
+			Comments: d.comments(obj),
+			Position: pos,
+		}
+	case "PackageExpr":
+		return &PackageExpr{Name: d.lit(obj["name"]), Position: pos}
+	case "ImportExpr":
+		return &ImportExpr{Path: d.lit(obj["path"]), Position: pos}
+	case "UsingExpr":
+		return &UsingExpr{Target: d.expr(obj["target"]), Position: pos}
+	case "TypeExpr":
+		return &TypeExpr{Target: d.ident(obj["target"]), Position: pos}
+	case "RecordType":
+		fields := d.list(obj["fields"])
+		r := &RecordType{Fields: make([]Decl, 0, len(fields)), Position: pos}
+		for _, raw := range fields {
+			r.Fields = append(r.Fields, d.decl(raw))
+		}
+		return r
+	case "TemplLit":
+		params := d.list(obj["params"])
+		t := &TemplLit{Params: make([]*Field, 0, len(params)), Body: d.markups(obj["body"]), Position: pos}
+		for _, raw := range params {
+			if f, ok := d.node(raw).(*Field); ok {
+				t.Params = append(t.Params, f)
+			} else {
+				d.errorf("expected Field")
+			}
+		}
+		return t
+	case "SelectorExpr":
+		return &SelectorExpr{X: d.expr(obj["x"]), Sel: d.ident(obj["sel"]), Position: pos}
+	case "ListType":
+		return &ListType{Elem: d.expr(obj["elem"]), Position: pos}
+	case "CallExpr":
+		args := d.list(obj["args"])
+		c := &CallExpr{Fun: d.expr(obj["fun"]), Args: make([]Expr, 0, len(args)), Position: pos}
+		for _, raw := range args {
+			c.Args = append(c.Args, d.expr(raw))
+		}
+		return c
+	case "ParenExpr":
+		return &ParenExpr{X: d.expr(obj["x"]), Position: pos}
+	case "Element":
+		return &Element{
+			Name:     d.ident(obj["name"]),
+			Attrs:    d.attrs(obj["attrs"]),
+			Children: d.markups(obj["children"]),
+			Position: pos,
+		}
+	case "Text":
+		t := &Text{Position: pos}
+		d.value(obj["value"], &t.Value)
+		return t
+	case "Interp":
+		return &Interp{X: d.expr(obj["x"]), Position: pos}
+	case "IfElement":
+		n := &IfElement{Cond: d.expr(obj["cond"]), Children: d.markups(obj["children"]), Position: pos}
+		if raw, ok := obj["else"]; ok {
+			if n.Else, ok = d.node(raw).(*ElseElement); !ok {
+				d.errorf("expected ElseElement")
+			}
+		}
+		return n
+	case "ElseElement":
+		return &ElseElement{Children: d.markups(obj["children"]), Position: pos}
+	case "ForElement":
+		n := &ForElement{Value: d.ident(obj["value"]), X: d.expr(obj["x"]), Children: d.markups(obj["children"]), Position: pos}
+		if raw, ok := obj["index"]; ok {
+			n.Index = d.ident(raw)
+		}
+		return n
+	default:
+		d.errorf("unknown node %q", kind)
+		return nil
+	}
+}
+
+// relink replaces the docs and tags of the declarations with the docs and
+// tags at the same positions in their declaration lists and the comments
+// of the nodes with the comment groups of n at the same positions.
+func relink(n *Namespace) {
+	relinkAnnotations(n.decl)
+
+	groups := map[Position]*CommentGroup{}
+	for _, g := range n.comments {
+		groups[g.Position] = g
+	}
+	Inspect(n, func(node Node) bool {
+		if r, ok := node.(*RecordType); ok {
+			relinkAnnotations(r.Fields)
+		}
+		c := CommentsOf(node)
+		if c == nil {
+			return true
+		}
+		if c.Leading != nil && groups[c.Leading.Position] != nil {
+			c.Leading = groups[c.Leading.Position]
+		}
+		if c.Trailing != nil && groups[c.Trailing.Position] != nil {
+			c.Trailing = groups[c.Trailing.Position]
+		}
+		return true
+	})
+}
+
+func relinkAnnotations(decls []Decl) {
+	docs := map[Position]*Doc{}
+	tags := map[Position]*Tag{}
+	for _, d := range decls {
+		switch d := d.(type) {
+		case *Doc:
+			docs[d.Position] = d
+		case *Tag:
+			tags[d.Position] = d
+		}
+	}
+	for _, d := range decls {
+		a, ok := d.(Annotated)
+		if !ok {
+			continue
+		}
+		as := annotationsOf(a)
+		for i, doc := range as.docs {
+			if same := docs[doc.Position]; same != nil {
+				as.docs[i] = same
+			}
+		}
+		for i, tag := range as.tags {
+			if same := tags[tag.Position]; same != nil {
+				as.tags[i] = same
+			}
+		}
+	}
+}
+
+func annotationsOf(a Annotated) *Annotations {
+	switch a := a.(type) {
+	case *PackageDecl:
+		return &a.Annotations
+	case *ImportDecl:
+		return &a.Annotations
+	case *UsingDecl:
+		return &a.Annotations
+	case *TypeDecl:
+		return &a.Annotations
+	case *TemplDecl:
+		return &a.Annotations
+	case *Field:
+		return &a.Annotations
+	default:
+		panic(fmt.Sprintf("unreachable: %T", a))
+	}
+}
@@ -0,0 +1,136 @@
+package ast_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"temlang/tem/ast"
+	"temlang/tem/parser"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const jsonSrc = `// package
+p :: #html package("a")
+
+// the user
+T : "documentation"
+T : { key = "value"; other = "x" }
+T :: record{
+	name: String // trailing
+	name: "the name"
+	}
+T :: templ(t: T){ <a href=(t.url) Name: (upper((t.name))) ("x")/> }
+`
+
+func TestJSONRoundTrip(t *testing.T) {
+	srcs := map[string][]byte{"src.tem": []byte(jsonSrc)}
+	files, err := filepath.Glob("../parser/testdata/*.tem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srcs[filepath.Base(file)] = src
+	}
+
+	for name, src := range srcs {
+		t.Run(name, func(t *testing.T) {
+			ns, _ := parser.ParseFile(name, src, parser.ParseComments())
+			b, err := json.Marshal(ns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got ast.Namespace
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			opts := cmp.AllowUnexported(ast.Namespace{}, ast.Annotations{})
+			if diff := cmp.Diff(ns, &got, opts); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestJSONShared(t *testing.T) {
+	ns, errs := parser.ParseFile("src.tem", []byte(jsonSrc), parser.ParseComments())
+	if errs.Len() != 0 {
+		t.Fatal("ParseFile failed unexpectedly")
+	}
+	b, err := json.Marshal(ns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got ast.Namespace
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	decls := got.Decls()
+	doc, tag, record := decls[1].(*ast.Doc), decls[2].(*ast.Tag), decls[3].(*ast.TypeDecl)
+	if d := record.Doc(); len(d) != 1 || d[0] != doc {
+		t.Errorf("expected the doc of T to be %p got %v", doc, d)
+	}
+	if tags := record.Tags(); len(tags) != 1 || tags[0] != tag {
+		t.Errorf("expected the tag of T to be %p got %v", tag, tags)
+	}
+	fields := record.Value.(*ast.RecordType).Fields
+	if d := fields[0].(*ast.Field).Doc(); len(d) != 1 || d[0] != fields[1] {
+		t.Errorf("expected the doc of name to be %p got %v", fields[1], d)
+	}
+	if g := doc.Leading; g == nil || g != got.Comments()[1] {
+		t.Errorf("expected the leading comment of the doc to be %v got %v", got.Comments()[1], g)
+	}
+}
+
+func TestJSONPositions(t *testing.T) {
+	ns, _ := parser.ParseFile("src.tem", []byte(jsonSrc))
+	b, err := json.Marshal(ns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tree struct {
+		Decls []struct {
+			Node  string
+			Names []struct {
+				Name  string
+				Start struct{ Offset, Line, Col int }
+			}
+		}
+	}
+	if err := json.Unmarshal(b, &tree); err != nil {
+		t.Fatal(err)
+	}
+	d := tree.Decls[3]
+	if d.Node != "TypeDecl" || len(d.Names) != 1 {
+		t.Fatalf("unexpected declaration %+v", d)
+	}
+	name := d.Names[0]
+	offset := strings.Index(jsonSrc, "T :: record")
+	if name.Name != "T" || name.Start.Offset != offset || name.Start.Line != 7 || name.Start.Col != 1 {
+		t.Errorf("unexpected name %+v", name)
+	}
+}
+
+func TestJSONDecodeError(t *testing.T) {
+	testcases := []string{
+		`[]`,
+		`{"node": "File"}`,
+		`{"node": "Namespace", "decls": [{"node": "Unknown"}]}`,
+		`{"node": "Namespace", "decls": [{"node": "Text", "value": "x"}]}`,
+		`{"node": "Namespace", "decls": [{"node": "TypeDecl", "names": [{"node": "BasicLit"}]}]}`,
+		`{"node": "Namespace", "decls": [{"node": "Doc", "text": [{"node": "BasicLit", "kind": "ident?"}]}]}`,
+	}
+	for _, src := range testcases {
+		var ns ast.Namespace
+		if err := json.Unmarshal([]byte(src), &ns); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded unexpectedly", src)
+		}
+	}
+}
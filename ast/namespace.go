@@ -1,5 +1,9 @@
 package ast
 
+import (
+	"temlang/tem/token"
+)
+
 func New(file, name string) *Namespace {
 	return &Namespace{
 		file: file,
@@ -8,21 +12,112 @@ func New(file, name string) *Namespace {
 }
 
 type Namespace struct {
-	pkg   string
-	name  string
-	file  string
-	decl  []SExpressionPrinter
-	lines []int
+	pkg    string
+	name   string
+	file   string
+	decl   []Decl
+	lines  []int
+	scope  *Scope
+	templs *Scope
+	// comments are all the comment groups of the file in source order
+	comments []*CommentGroup
 }
 
 func (n *Namespace) SetPackageName(name string) {
 	n.pkg = name
 }
 
-func (n *Namespace) Add(d SExpressionPrinter) {
+func (n *Namespace) Add(d Decl) {
 	n.decl = append(n.decl, d)
 }
 
 func (n *Namespace) SetLines(lines []int) {
 	n.lines = lines
 }
+
+func (n *Namespace) PackageName() string {
+	return n.pkg
+}
+
+func (n *Namespace) Name() string {
+	return n.name
+}
+
+func (n *Namespace) File() string {
+	return n.file
+}
+
+func (n *Namespace) Decls() []Decl {
+	return n.decl
+}
+
+func (n *Namespace) Lines() []int {
+	return n.lines
+}
+
+func (n *Namespace) SetComments(comments []*CommentGroup) {
+	n.comments = comments
+}
+
+// Comments returns the comment groups of the namespace, nil unless the
+// comments were parsed.
+func (n *Namespace) Comments() []*CommentGroup {
+	return n.comments
+}
+
+func (n *Namespace) SetScope(scope, templs *Scope) {
+	n.scope = scope
+	n.templs = templs
+}
+
+// Scope returns the scope of the declarations of the namespace, nil if
+// the namespace is not resolved.
+func (n *Namespace) Scope() *Scope {
+	return n.scope
+}
+
+// Templs returns the scope of the templs of the namespace. A templ can
+// have the same name as the type it renders so templs have a scope of
+// their own.
+func (n *Namespace) Templs() *Scope {
+	return n.templs
+}
+
+// Pos spans the declarations of the namespace
+func (n *Namespace) Pos() Position {
+	if len(n.decl) == 0 {
+		return Position{}
+	}
+	last := n.decl[len(n.decl)-1]
+	return Position{Start: 0, End: last.Pos().End}
+}
+
+// LineCol returns the 1 based line and column of offset.
+func (n *Namespace) LineCol(offset int) token.Pos {
+	return token.NewFile(n.file, n.lines).LineCol(offset)
+}
+
+func (n *Namespace) Location(pos Position) token.Location {
+	return token.Location{
+		Start: n.LineCol(pos.Start),
+		End:   n.LineCol(pos.End),
+		File:  n.file,
+	}
+}
+
+// Diagnostic returns err located in the file of the namespace.
+func (n *Namespace) Diagnostic(err token.Error) token.Diagnostic {
+	d := token.Diagnostic{
+		Location: n.Location(Position{Start: err.Offset(), End: err.End()}),
+		Severity: token.SeverityError,
+		Code:     err.Code(),
+		Message:  err.Message(),
+	}
+	for _, r := range err.Related() {
+		d.Related = append(d.Related, token.Related{
+			Location: n.Location(Position{Start: r.Offset(), End: r.End()}),
+			Message:  r.Message(),
+		})
+	}
+	return d
+}
@@ -0,0 +1,93 @@
+package ast
+
+type ObjKind int
+
+const (
+	Bad ObjKind = iota
+	// Pkg the package declared by a namespace
+	Pkg
+	// Imp an imported package
+	Imp
+	// Typ a type, a record or a name declared by using
+	Typ
+	// Tmpl a template
+	Tmpl
+	// Var a templ parameter or a top level var
+	Var
+	// Builtin a type declared in the universe scope
+	Builtin
+	// Helper a function declared in the universe scope, called in the
+	// interpolations
+	Helper
+)
+
+func (k ObjKind) String() string {
+	switch k {
+	case Pkg:
+		return "package"
+	case Imp:
+		return "import"
+	case Typ:
+		return "type"
+	case Tmpl:
+		return "templ"
+	case Var:
+		return "var"
+	case Builtin:
+		return "builtin"
+	case Helper:
+		return "helper"
+	default:
+		return "bad"
+	}
+}
+
+// Object is a named entity declared in a scope. Decl is the node declaring
+// it or nil for the objects of the universe scope.
+type Object struct {
+	Kind ObjKind
+	Name string
+	Decl Node
+	// Target is the type a name declared by using denotes in another
+	// namespace, nil until the namespaces of the package are linked.
+	Target *Object
+}
+
+func NewObj(kind ObjKind, name string, decl Node) *Object {
+	return &Object{Kind: kind, Name: name, Decl: decl}
+}
+
+type Scope struct {
+	Outer   *Scope
+	Objects map[string]*Object
+}
+
+func NewScope(outer *Scope) *Scope {
+	return &Scope{Outer: outer, Objects: map[string]*Object{}}
+}
+
+// Lookup returns the object with the given name in s or nil. The outer
+// scopes are not searched.
+func (s *Scope) Lookup(name string) *Object {
+	return s.Objects[name]
+}
+
+// LookupParent searches s and its outer scopes for the object with the
+// given name.
+func (s *Scope) LookupParent(name string) *Object {
+	for ; s != nil; s = s.Outer {
+		if obj := s.Lookup(name); obj != nil {
+			return obj
+		}
+	}
+	return nil
+}
+
+// Insert inserts obj into s unless s already contains an object with the
+// same name. In that case the existing object is returned.
+func (s *Scope) Insert(obj *Object) (alt *Object) {
+	if alt = s.Objects[obj.Name]; alt == nil {
+		s.Objects[obj.Name] = obj
+	}
+	return
+}
@@ -2,85 +2,262 @@ package ast
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"temlang/tem/token"
 )
 
-type SExprPrinterContext interface {
-	Location(start, end int) string
+type sexprLine struct {
+	expr  string
+	value string
+	loc   string
+}
 
-	WriteString(string, ...any)
+type sexprPrinter struct {
+	lines  []sexprLine
+	indent int
+	ns     *Namespace
+}
 
-	Indent()
+func (p *sexprPrinter) location(pos Position) string {
+	l := p.ns.Location(pos)
+	return fmt.Sprintf("%d, %d - %d, %d", l.Start.Line, l.Start.Col, l.End.Line, l.End.Col)
+}
 
-	Dedent()
+// open writes the line of a node and indents the lines of its children
+func (p *sexprPrinter) open(label string, pos Position) {
+	expr := fmt.Sprintf("%s(%s", strings.Repeat(" ", p.indent), label)
+	p.lines = append(p.lines, sexprLine{expr: expr, loc: p.location(pos)})
+	p.indent += 2
+}
 
-	Indentation() string
+// close closes the node on the last line written
+func (p *sexprPrinter) close() {
+	p.lines[len(p.lines)-1].expr += ")"
+	p.indent -= 2
 }
 
-type SExpressionPrinter interface {
-	WriteSExpr(SExprPrinterContext)
+func (p *sexprPrinter) leaf(label, value string, pos Position) {
+	p.open(label, pos)
+	p.lines[len(p.lines)-1].value = quoteSExpr(value)
+	p.close()
 }
 
-type sexprPrinter struct {
-	sb          strings.Builder
-	indent      int
-	indentation string
-	ns          *Namespace
+// empty writes a node without position or children
+func (p *sexprPrinter) empty(label string) {
+	expr := fmt.Sprintf("%s(%s)", strings.Repeat(" ", p.indent), label)
+	p.lines = append(p.lines, sexprLine{expr: expr})
 }
 
-func (p *sexprPrinter) lineOffsetFor(offset int) (line int, lineOffset int) {
-	for i, l := range p.ns.lines {
-		if l >= offset {
-			break
-		}
-		line = i
-		lineOffset = l
-	}
-	// 1 based line numbers
-	line += 1
-	return
+func (p *sexprPrinter) ident(ident *Ident) {
+	p.leaf("identifier", ident.Name, ident.Position)
 }
 
-func (p *sexprPrinter) Location(start, end int) string {
-	startLine, startOffset := p.lineOffsetFor(start)
-	endLine, endOffset := p.lineOffsetFor(end)
+func (p *sexprPrinter) idents(label string, idents []*Ident) {
+	if len(idents) == 0 {
+		p.empty(label)
+		return
+	}
+	start := idents[0].Start
+	end := idents[len(idents)-1].End
+	p.open(label, Position{Start: start, End: end})
+	for _, ident := range idents {
+		p.ident(ident)
+	}
+	p.close()
+}
 
-	startCol := start - startOffset
-	endCol := end - endOffset
+func (p *sexprPrinter) lit(lit *BasicLit) {
+	switch lit.Kind {
+	case token.TextBlock:
+		p.leaf("text_block", lit.Value, lit.Position)
+	default:
+		p.leaf("string", lit.Value, lit.Position)
+	}
+}
 
-	if start == 0 && startCol == 0 {
-		startCol = 1
+func (p *sexprPrinter) list(label string, nodes []Node) {
+	if len(nodes) == 0 {
+		p.empty(label)
+		return
 	}
+	start := nodes[0].Pos().Start
+	end := nodes[len(nodes)-1].Pos().End
+	p.open(label, Position{Start: start, End: end})
+	for _, n := range nodes {
+		p.node(n)
+	}
+	p.close()
+}
 
-	// 1 based col numbers
-	// startCol += 1
-	// endCol += 1
+func (p *sexprPrinter) annotation(t *Ident) {
+	if t == nil {
+		p.empty("type")
+		return
+	}
+	p.open("type", t.Position)
+	p.ident(t)
+	p.close()
+}
 
-	return fmt.Sprintf("%d, %d - %d, %d", startLine, startCol, endLine, endCol)
+func (p *sexprPrinter) expr(label string, e Expr) {
+	if e == nil {
+		p.empty(label)
+		return
+	}
+	p.open(label, e.Pos())
+	p.node(e)
+	p.close()
 }
 
-func (p *sexprPrinter) Indent() {
-	p.indent += 2
-	i := strings.Repeat(" ", p.indent)
-	p.indentation = i
+func (p *sexprPrinter) decl(label string, names []*Ident, t *Ident, directives []*Ident, e Expr, pos Position) {
+	p.open(label, pos)
+	p.idents("identifiers", names)
+	p.annotation(t)
+	p.idents("directives", directives)
+	p.expr("expr", e)
+	p.close()
 }
 
-func (p *sexprPrinter) Dedent() {
-	p.indent -= 2
-	if p.indent < 0 {
-		p.indent = 0
+func (p *sexprPrinter) node(n Node) {
+	switch n := n.(type) {
+	case *PackageDecl:
+		p.decl("package_declaration", n.Names, n.Type, n.Directives, n.Value, n.Position)
+	case *ImportDecl:
+		p.decl("import_declaration", n.Names, n.Type, n.Directives, n.Value, n.Position)
+	case *UsingDecl:
+		p.decl("using_declaration", n.Names, n.Type, n.Directives, n.Value, n.Position)
+	case *TypeDecl:
+		p.decl("type_declaration", n.Names, n.Type, n.Directives, n.Value, n.Position)
+	case *TemplDecl:
+		p.decl("templ_declaration", n.Names, n.Type, n.Directives, n.Value, n.Position)
+	case *Field:
+		p.open("var_declaration", n.Position)
+		p.idents("identifiers", n.Names)
+		p.expr("type", n.Type)
+		p.close()
+	case *Doc:
+		p.open("doc_declaration", n.Position)
+		p.idents("identifiers", n.Names)
+		p.list("documentations", nodes(n.Text))
+		p.close()
+	case *Tag:
+		p.open("tag_declaration", n.Position)
+		p.idents("identifiers", n.Names)
+		p.list("attributes", nodes(n.Attrs))
+		p.close()
+	case *Attr:
+		p.open("attr", n.Position)
+		p.idents("identifiers", n.Names)
+		p.expr("expr", n.Value)
+		p.close()
+	case *PackageExpr:
+		p.open("pkg_expr", n.Position)
+		p.expr("name", n.Name)
+		p.close()
+	case *ImportExpr:
+		p.open("import_expr", n.Position)
+		p.expr("path", n.Path)
+		p.close()
+	case *UsingExpr:
+		p.open("using_expr", n.Position)
+		p.expr("target", n.Target)
+		p.close()
+	case *TypeExpr:
+		p.open("type_expr", n.Position)
+		p.expr("target", n.Target)
+		p.close()
+	case *RecordType:
+		p.open("record_expr", n.Position)
+		p.list("fields", nodes(n.Fields))
+		p.close()
+	case *TemplLit:
+		p.open("templ_expr", n.Position)
+		p.list("params", nodes(n.Params))
+		p.list("elements", nodes(n.Body))
+		p.close()
+	case *SelectorExpr:
+		p.open("selector_expr", n.Position)
+		p.node(n.X)
+		p.ident(n.Sel)
+		p.close()
+	case *ListType:
+		p.open("list_type", n.Position)
+		p.node(n.Elem)
+		p.close()
+	case *CallExpr:
+		p.open("call_expr", n.Position)
+		p.expr("function", n.Fun)
+		p.list("arguments", nodes(n.Args))
+		p.close()
+	case *ParenExpr:
+		p.open("parenthesized_expr", n.Position)
+		p.node(n.X)
+		p.close()
+	case *Element:
+		p.open("element", n.Position)
+		p.expr("name", n.Name)
+		p.list("attributes", nodes(n.Attrs))
+		p.list("children", nodes(n.Children))
+		p.close()
+	case *Text:
+		p.leaf("text", n.Value, n.Position)
+	case *Interp:
+		p.open("interpolation", n.Position)
+		p.expr("expr", n.X)
+		p.close()
+	case *IfElement:
+		p.open("if_element", n.Position)
+		p.expr("condition", n.Cond)
+		p.list("children", nodes(n.Children))
+		if n.Else != nil {
+			p.node(n.Else)
+		}
+		p.close()
+	case *ElseElement:
+		p.open("else_element", n.Position)
+		p.list("children", nodes(n.Children))
+		p.close()
+	case *ForElement:
+		p.open("for_element", n.Position)
+		if n.Index != nil {
+			p.expr("index", n.Index)
+		}
+		p.expr("value", n.Value)
+		p.expr("range", n.X)
+		p.list("children", nodes(n.Children))
+		p.close()
+	case *Ident:
+		p.ident(n)
+	case *BasicLit:
+		p.lit(n)
+	case *BadDecl:
+		p.open("ERROR", n.Position)
+		p.close()
+	case *BadExpr:
+		p.open("ERROR", n.Position)
+		p.close()
+	default:
+		p.empty("ERROR")
 	}
-	i := strings.Repeat(" ", p.indent)
-	p.indentation = i
 }
 
-func (p *sexprPrinter) Indentation() string {
-	return p.indentation
+func nodes[T Node](ts []T) []Node {
+	ns := make([]Node, 0, len(ts))
+	for _, t := range ts {
+		ns = append(ns, t)
+	}
+	return ns
 }
 
-func (p *sexprPrinter) WriteString(str string, obj ...any) {
-	s := fmt.Sprintf(str, obj...)
-	p.sb.WriteString(s)
+// quoteSExpr quotes a value with single quotes escaping the quote and
+// control characters.
+func quoteSExpr(s string) string {
+	q := strconv.Quote(s)
+	q = q[1 : len(q)-1]
+	q = strings.ReplaceAll(q, `\"`, `"`)
+	q = strings.ReplaceAll(q, `'`, `\'`)
+	return "'" + q + "'"
 }
 
 func PrintSExpr(n *Namespace) string {
@@ -89,66 +266,35 @@ func PrintSExpr(n *Namespace) string {
 	}
 
 	for _, d := range n.decl {
-		d.WriteSExpr(&p)
+		p.node(d)
 	}
 
-	sexpr := make([]string, 0, p.sb.Len())
-	chunks := make([][]string, 0, p.sb.Len())
-	fmtLines := strings.Builder{}
-
-	fmtLines.Grow(len(sexpr))
-
-	str := p.sb.String()
-	lines := strings.Split(str, "\n")
-
-	longestSExpr := 0
-	for _, line := range lines {
-		chunk := strings.Split(line, ";")
-		if l := len(chunk[0]); l > longestSExpr {
-			longestSExpr = l
+	longestExpr := 0
+	longestValue := 0
+	for _, line := range p.lines {
+		if l := len(line.expr); l > longestExpr {
+			longestExpr = l
+		}
+		if l := len(line.value); l > longestValue {
+			longestValue = l
 		}
-		chunks = append(chunks, chunk)
 	}
 
-	for _, chunk := range chunks {
-		length := len(chunk)
-		if length == 0 {
+	var sb strings.Builder
+	for _, line := range p.lines {
+		if line.loc == "" {
+			sb.WriteString(line.expr)
+			sb.WriteString("\n")
 			continue
 		}
-		if length != 2 {
-			fmtLines.WriteString(strings.Join(chunk, ""))
-			continue
-		}
-
-		e := chunk[0]
-		l := chunk[1]
-		length = len(e)
-
-		if strings.Contains(e, "=") {
-			chunks := strings.Split(e, "=")
-			if len(chunks) != 2 {
-				break
-			}
-			left := chunks[0]
-			right := chunks[1]
-
-			pad := longestSExpr - length
-			left = left + strings.Repeat(" ", pad+1)
-
-			e = left + right
-		}
-
-		var str string
-
-		if length == longestSExpr {
-			str = e
-		} else {
-			padSize := longestSExpr - len(e)
-			pad := strings.Repeat(" ", padSize)
-			str = fmt.Sprintf("%s%s", e, pad)
-		}
-		fmtLines.WriteString(fmt.Sprintf("%s  ; %s\n", str, l))
+		sb.WriteString(line.expr)
+		sb.WriteString(strings.Repeat(" ", longestExpr-len(line.expr)+1))
+		sb.WriteString(strings.Repeat(" ", longestValue-len(line.value)))
+		sb.WriteString(line.value)
+		sb.WriteString("  ; ")
+		sb.WriteString(line.loc)
+		sb.WriteString("\n")
 	}
 
-	return fmtLines.String()
+	return sb.String()
 }
@@ -0,0 +1,168 @@
+package ast
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"temlang/tem/token"
+)
+
+// SExpr is a node of the s-expression printed by PrintSExpr.
+//
+//	(identifiers              ; 1, 1 - 1, 2
+//	  (identifier))      'p'  ; 1, 1 - 1, 2
+type SExpr struct {
+	Label string
+	// Value is the unquoted value of a leaf like an identifier or a string.
+	Value string
+	// Start and End are zero for a node printed without location.
+	Start, End token.Pos
+	Children   []*SExpr
+}
+
+// ParseSExpr reads the s-expressions printed by PrintSExpr back. The value
+// and the location at the end of a line belong to the last node opened on
+// the line.
+func ParseSExpr(src string) ([]*SExpr, error) {
+	r := sexprReader{src: src, line: 1}
+	return r.read()
+}
+
+type sexprReader struct {
+	src    string
+	offset int
+	line   int
+	// stack holds the open nodes
+	stack []*SExpr
+	// last is the last node opened on the current line
+	last  *SExpr
+	exprs []*SExpr
+}
+
+func (r *sexprReader) errorf(format string, args ...any) error {
+	return fmt.Errorf("sexpr:%d: %s", r.line, fmt.Sprintf(format, args...))
+}
+
+func (r *sexprReader) read() ([]*SExpr, error) {
+	for r.offset < len(r.src) {
+		switch c := r.src[r.offset]; c {
+		case '\n':
+			r.offset += 1
+			r.line += 1
+			r.last = nil
+		case ' ', '\t', '\r':
+			r.offset += 1
+		case '(':
+			r.offset += 1
+			r.open(r.label())
+		case ')':
+			r.offset += 1
+			if len(r.stack) == 0 {
+				return nil, r.errorf("unexpected )")
+			}
+			r.stack = r.stack[:len(r.stack)-1]
+		case '\'':
+			value, err := r.value()
+			if err != nil {
+				return nil, err
+			}
+			if r.last == nil {
+				return nil, r.errorf("value %q of no node", value)
+			}
+			r.last.Value = value
+		case ';':
+			if err := r.location(); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, r.errorf("unexpected %q", c)
+		}
+	}
+	if len(r.stack) != 0 {
+		return nil, r.errorf("missing ) of %s", r.stack[len(r.stack)-1].Label)
+	}
+	return r.exprs, nil
+}
+
+func (r *sexprReader) open(label string) {
+	n := &SExpr{Label: label}
+	if len(r.stack) == 0 {
+		r.exprs = append(r.exprs, n)
+	} else {
+		parent := r.stack[len(r.stack)-1]
+		parent.Children = append(parent.Children, n)
+	}
+	r.stack = append(r.stack, n)
+	r.last = n
+}
+
+func (r *sexprReader) label() string {
+	start := r.offset
+	for r.offset < len(r.src) {
+		c := r.src[r.offset]
+		if c == '(' || c == ')' || c == ' ' || c == '\n' {
+			break
+		}
+		r.offset += 1
+	}
+	return r.src[start:r.offset]
+}
+
+// value reads a value quoted by quoteSExpr.
+func (r *sexprReader) value() (string, error) {
+	var b strings.Builder
+	b.WriteByte('"')
+	r.offset += 1
+	for {
+		if r.offset >= len(r.src) || r.src[r.offset] == '\n' {
+			return "", r.errorf("unterminated value")
+		}
+		c := r.src[r.offset]
+		r.offset += 1
+		switch c {
+		case '\'':
+			b.WriteByte('"')
+			s, err := strconv.Unquote(b.String())
+			if err != nil {
+				return "", r.errorf("invalid value %s", b.String())
+			}
+			return s, nil
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			if r.offset >= len(r.src) {
+				return "", r.errorf("unterminated value")
+			}
+			e := r.src[r.offset]
+			r.offset += 1
+			if e != '\'' {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(e)
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+// location reads the location comment ending a line.
+func (r *sexprReader) location() error {
+	end := strings.IndexByte(r.src[r.offset:], '\n')
+	if end < 0 {
+		end = len(r.src) - r.offset
+	}
+	comment := r.src[r.offset+1 : r.offset+end]
+	r.offset += end
+
+	if r.last == nil {
+		return r.errorf("location of no node")
+	}
+	var start, stop token.Pos
+	_, err := fmt.Sscanf(comment, "%d, %d - %d, %d", &start.Line, &start.Col, &stop.Line, &stop.Col)
+	if err != nil {
+		return r.errorf("invalid location %q", strings.TrimSpace(comment))
+	}
+	r.last.Start = start
+	r.last.End = stop
+	return nil
+}
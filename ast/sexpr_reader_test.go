@@ -0,0 +1,79 @@
+package ast_test
+
+import (
+	"temlang/tem/ast"
+	"temlang/tem/parser"
+	"temlang/tem/token"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func pos(line, col int) token.Pos {
+	return token.Pos{Line: line, Col: col}
+}
+
+func TestParseSExpr(t *testing.T) {
+	src := "p :: package(\"m\")\nT : \"it's (a) \\\"doc\\\"; really\"\nT : String\n"
+	ns, errs := parser.ParseFile("test.tem", []byte(src))
+	if errs.Len() != 0 {
+		t.Fatal("ParseFile failed unexpectedly")
+	}
+	got, err := ast.ParseSExpr(ast.PrintSExpr(ns))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*ast.SExpr{
+		{Label: "package_declaration", Start: pos(1, 1), End: pos(1, 18), Children: []*ast.SExpr{
+			{Label: "identifiers", Start: pos(1, 1), End: pos(1, 2), Children: []*ast.SExpr{
+				{Label: "identifier", Value: "p", Start: pos(1, 1), End: pos(1, 2)},
+			}},
+			{Label: "type"},
+			{Label: "directives"},
+			{Label: "expr", Start: pos(1, 6), End: pos(1, 18), Children: []*ast.SExpr{
+				{Label: "pkg_expr", Start: pos(1, 6), End: pos(1, 18), Children: []*ast.SExpr{
+					{Label: "name", Start: pos(1, 14), End: pos(1, 17), Children: []*ast.SExpr{
+						{Label: "string", Value: `"m"`, Start: pos(1, 14), End: pos(1, 17)},
+					}},
+				}},
+			}},
+		}},
+		{Label: "doc_declaration", Start: pos(2, 1), End: pos(2, 31), Children: []*ast.SExpr{
+			{Label: "identifiers", Start: pos(2, 1), End: pos(2, 2), Children: []*ast.SExpr{
+				{Label: "identifier", Value: "T", Start: pos(2, 1), End: pos(2, 2)},
+			}},
+			{Label: "documentations", Start: pos(2, 5), End: pos(2, 31), Children: []*ast.SExpr{
+				{Label: "string", Value: `"it's (a) \"doc\"; really"`, Start: pos(2, 5), End: pos(2, 31)},
+			}},
+		}},
+		{Label: "var_declaration", Start: pos(3, 1), End: pos(3, 11), Children: []*ast.SExpr{
+			{Label: "identifiers", Start: pos(3, 1), End: pos(3, 2), Children: []*ast.SExpr{
+				{Label: "identifier", Value: "T", Start: pos(3, 1), End: pos(3, 2)},
+			}},
+			{Label: "type", Start: pos(3, 5), End: pos(3, 11), Children: []*ast.SExpr{
+				{Label: "identifier", Value: "String", Start: pos(3, 5), End: pos(3, 11)},
+			}},
+		}},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestParseSExprError(t *testing.T) {
+	testcases := []string{
+		"(a",
+		"(a))",
+		"(a) 'value",
+		"'value'",
+		"; 1, 1 - 1, 2",
+		"(a) ; 1, 1",
+		"(a) x",
+	}
+	for _, src := range testcases {
+		if _, err := ast.ParseSExpr(src); err == nil {
+			t.Errorf("ParseSExpr(%q) succeeded unexpectedly", src)
+		}
+	}
+}
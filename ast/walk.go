@@ -0,0 +1,149 @@
+package ast
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+func walkList[T Node](v Visitor, list []T) {
+	for _, n := range list {
+		Walk(v, n)
+	}
+}
+
+func walkDecl(v Visitor, names []*Ident, t *Ident, directives []*Ident, value Expr) {
+	walkList(v, names)
+	if t != nil {
+		Walk(v, t)
+	}
+	walkList(v, directives)
+	if value != nil {
+		Walk(v, value)
+	}
+}
+
+// Walk traverses an AST in depth-first order: It starts by calling
+// v.Visit(node); node must not be nil. If the visitor w returned by
+// v.Visit(node) is not nil, Walk is invoked recursively with visitor
+// w for each of the non-nil children of node, followed by a call of
+// w.Visit(nil).
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	c := CommentsOf(node)
+	if c != nil && c.Leading != nil {
+		Walk(v, c.Leading)
+	}
+
+	switch n := node.(type) {
+	case *Namespace:
+		walkList(v, n.decl)
+
+	case *PackageDecl:
+		walkDecl(v, n.Names, n.Type, n.Directives, n.Value)
+	case *ImportDecl:
+		walkDecl(v, n.Names, n.Type, n.Directives, n.Value)
+	case *UsingDecl:
+		walkDecl(v, n.Names, n.Type, n.Directives, n.Value)
+	case *TypeDecl:
+		walkDecl(v, n.Names, n.Type, n.Directives, n.Value)
+	case *TemplDecl:
+		walkDecl(v, n.Names, n.Type, n.Directives, n.Value)
+
+	case *Field:
+		walkList(v, n.Names)
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+	case *Doc:
+		walkList(v, n.Names)
+		walkList(v, n.Text)
+	case *Tag:
+		walkList(v, n.Names)
+		walkList(v, n.Attrs)
+	case *Attr:
+		walkList(v, n.Names)
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+
+	case *PackageExpr:
+		Walk(v, n.Name)
+	case *ImportExpr:
+		Walk(v, n.Path)
+	case *UsingExpr:
+		Walk(v, n.Target)
+	case *TypeExpr:
+		Walk(v, n.Target)
+	case *RecordType:
+		walkList(v, n.Fields)
+	case *TemplLit:
+		walkList(v, n.Params)
+		walkList(v, n.Body)
+	case *SelectorExpr:
+		Walk(v, n.X)
+		Walk(v, n.Sel)
+	case *ListType:
+		Walk(v, n.Elem)
+	case *CallExpr:
+		Walk(v, n.Fun)
+		walkList(v, n.Args)
+	case *ParenExpr:
+		Walk(v, n.X)
+
+	case *Element:
+		Walk(v, n.Name)
+		walkList(v, n.Attrs)
+		walkList(v, n.Children)
+	case *Interp:
+		Walk(v, n.X)
+	case *IfElement:
+		Walk(v, n.Cond)
+		walkList(v, n.Children)
+		if n.Else != nil {
+			Walk(v, n.Else)
+		}
+	case *ElseElement:
+		walkList(v, n.Children)
+	case *ForElement:
+		if n.Index != nil {
+			Walk(v, n.Index)
+		}
+		Walk(v, n.Value)
+		Walk(v, n.X)
+		walkList(v, n.Children)
+
+	case *CommentGroup:
+		walkList(v, n.List)
+
+	case *Ident, *BasicLit, *Text, *Comment, *BadDecl, *BadExpr:
+		// nothing to do
+	}
+
+	if c != nil && c.Trailing != nil {
+		Walk(v, c.Trailing)
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order: It starts by calling
+// f(node); node must not be nil. If f returns true, Inspect invokes f
+// recursively for each of the non-nil children of node, followed by a
+// call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,138 @@
+package ast_test
+
+import (
+	"temlang/tem/ast"
+	"temlang/tem/parser"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	src := `
+		p :: #html package("a")
+		T : "documentation"
+		T : { key = "value" }
+		T :: record{ name: String }
+		T :: templ(t: T){ <a href=(t.url) Name: (t.name)/> }
+	`
+
+	file, errs := parser.ParseFile("test.tem", []byte(src))
+	if errs.Len() != 0 {
+		t.Fatal("ParseFile failed unexpectedly")
+	}
+
+	var idents []string
+	counts := map[string]int{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			idents = append(idents, n.Name)
+		case *ast.BasicLit:
+			counts["lit"] += 1
+		case *ast.Element:
+			counts["element"] += 1
+		case *ast.Attr:
+			counts["attr"] += 1
+		case *ast.Interp:
+			counts["interp"] += 1
+		case *ast.Text:
+			counts["text"] += 1
+		case *ast.Field:
+			counts["field"] += 1
+		}
+		return true
+	})
+
+	want := []string{
+		"p", "html",
+		"T",
+		"T", "key",
+		"T", "name", "String",
+		"T", "t", "T", "a", "href", "t", "url", "t", "name",
+	}
+	if len(idents) != len(want) {
+		t.Fatalf("expected idents %v got %v", want, idents)
+	}
+	for i := range want {
+		if idents[i] != want[i] {
+			t.Errorf("expected ident %d to be %s got %s", i, want[i], idents[i])
+		}
+	}
+
+	wantCounts := map[string]int{
+		"lit":     3,
+		"element": 1,
+		"attr":    2,
+		"interp":  1,
+		"text":    1,
+		"field":   2,
+	}
+	for k, v := range wantCounts {
+		if counts[k] != v {
+			t.Errorf("expected %d %s got %d", v, k, counts[k])
+		}
+	}
+}
+
+type countVisitor struct {
+	visits int
+	exits  int
+}
+
+func (v *countVisitor) Visit(n ast.Node) ast.Visitor {
+	if n == nil {
+		v.exits += 1
+		return nil
+	}
+	v.visits += 1
+	// do not descend into records
+	if _, ok := n.(*ast.RecordType); ok {
+		return nil
+	}
+	return v
+}
+
+func TestWalk(t *testing.T) {
+	src := `p :: package("a"); R :: record{ name: String }`
+
+	file, errs := parser.ParseFile("test.tem", []byte(src))
+	if errs.Len() != 0 {
+		t.Fatal("ParseFile failed unexpectedly")
+	}
+
+	v := &countVisitor{}
+	ast.Walk(v, file)
+
+	// namespace, package decl, p, package expr, "a", type decl, R, record
+	if v.visits != 8 {
+		t.Errorf("expected 8 visits got %d", v.visits)
+	}
+	// every node visited except the record is exited
+	if v.exits != 7 {
+		t.Errorf("expected 7 exits got %d", v.exits)
+	}
+}
+
+func TestInspectComments(t *testing.T) {
+	src := `
+		p :: package("a")
+		// leading
+		T :: record{ name: String } // trailing
+	`
+
+	file, errs := parser.ParseFile("test.tem", []byte(src), parser.ParseComments())
+	if errs.Len() != 0 {
+		t.Fatal("ParseFile failed unexpectedly")
+	}
+
+	var comments []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		if c, ok := n.(*ast.Comment); ok {
+			comments = append(comments, c.Text)
+		}
+		return true
+	})
+
+	if len(comments) != 2 || comments[0] != "// leading" || comments[1] != "// trailing" {
+		t.Errorf("expected the leading and trailing comments in order got %q", comments)
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"temlang/tem/ast"
+	"temlang/tem/parser"
+	"temlang/tem/resolver"
+	"temlang/tem/token"
+	"temlang/tem/types"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// document is an open document parsed and checked on each change.
+type document struct {
+	uri     string
+	version int
+	src     []byte
+	ns      *ast.Namespace
+	errs    []token.Error
+}
+
+func newDocument(uri string, version int, text string) *document {
+	d := &document{uri: uri, version: version, src: []byte(text)}
+	ns, errs := parser.ParseFile(filename(uri), d.src)
+	if errs.Empty() {
+		types.Check(ns, errs)
+	} else {
+		// the names are resolved for the navigation whatever the syntax
+		// errors, the errors of the resolution would be noise
+		resolver.Resolve(ns, &token.ErrorQueue{})
+	}
+	for !errs.Empty() {
+		err, _ := errs.Pop()
+		d.errs = append(d.errs, err)
+	}
+	d.ns = ns
+	return d
+}
+
+// filename returns the path of a file URI or the URI itself.
+func filename(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return u.Path
+}
+
+// position returns the LSP position of offset.
+func (d *document) position(offset int) position {
+	offset = min(max(offset, 0), len(d.src))
+	pos := d.ns.LineCol(offset)
+	start := offset - (pos.Col - 1)
+	return position{Line: pos.Line - 1, Character: utf16Len(d.src[start:offset])}
+}
+
+func (d *document) rng(pos ast.Position) rng {
+	return rng{Start: d.position(pos.Start), End: d.position(pos.End)}
+}
+
+// offset returns the offset of an LSP position, the end of the line if
+// the character is past it.
+func (d *document) offset(p position) int {
+	lines := d.ns.Lines()
+	start := 0
+	switch {
+	case p.Line >= len(lines) && p.Line > 0:
+		return len(d.src)
+	case p.Line > 0:
+		start = lines[p.Line] + 1
+	}
+	offset, units := start, 0
+	for offset < len(d.src) && units < p.Character {
+		r, size := utf8.DecodeRune(d.src[offset:])
+		if r == '\n' {
+			break
+		}
+		units += len(utf16.Encode([]rune{r}))
+		offset += size
+	}
+	return offset
+}
+
+func utf16Len(b []byte) int {
+	n := 0
+	for _, r := range string(b) {
+		n += len(utf16.Encode([]rune{r}))
+	}
+	return n
+}
+
+func (d *document) diagnostics() []diagnostic {
+	diags := []diagnostic{}
+	for _, err := range d.errs {
+		end := err.End()
+		if end == err.Offset() {
+			// underline the word at the error
+			end = d.wordEnd(end)
+		}
+		diag := diagnostic{
+			Range:    rng{Start: d.position(err.Offset()), End: d.position(end)},
+			Severity: severityError,
+			Code:     string(err.Code()),
+			Source:   "tem",
+			Message:  err.Message(),
+		}
+		for _, r := range err.Related() {
+			diag.RelatedInformation = append(diag.RelatedInformation, diagnosticRelated{
+				Location: location{URI: d.uri, Range: d.rng(ast.Position{Start: r.Offset(), End: r.End()})},
+				Message:  r.Message(),
+			})
+		}
+		diags = append(diags, diag)
+	}
+	return diags
+}
+
+// wordEnd returns the end of the word at offset or of the char at offset
+// if it starts no word. A newline is not underlined.
+func (d *document) wordEnd(offset int) int {
+	end := offset
+	for end < len(d.src) {
+		r, size := utf8.DecodeRune(d.src[end:])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			break
+		}
+		end += size
+	}
+	if end == offset && offset < len(d.src) && d.src[offset] != '\n' {
+		_, size := utf8.DecodeRune(d.src[offset:])
+		end += size
+	}
+	return end
+}
+
+// identAt returns the identifier at offset, the offset right after an
+// identifier included.
+func (d *document) identAt(offset int) *ast.Ident {
+	var found *ast.Ident
+	for _, decl := range d.ns.Decls() {
+		if p := decl.Pos(); offset < p.Start || offset > p.End {
+			continue
+		}
+		ast.Inspect(decl, func(n ast.Node) bool {
+			if found != nil || n == nil {
+				return false
+			}
+			if id, ok := n.(*ast.Ident); ok && id.Start <= offset && offset <= id.End {
+				found = id
+			}
+			return true
+		})
+	}
+	return found
+}
+
+// declIdent returns the identifier declaring obj, nil for the objects of
+// the universe.
+func declIdent(obj *ast.Object) *ast.Ident {
+	if obj == nil || obj.Decl == nil {
+		return nil
+	}
+	var found *ast.Ident
+	ast.Inspect(obj.Decl, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Obj == obj && found == nil {
+			found = id
+		}
+		return found == nil
+	})
+	return found
+}
+
+func (d *document) definition(offset int) *location {
+	id := d.identAt(offset)
+	if id == nil {
+		return nil
+	}
+	decl := declIdent(id.Obj)
+	if decl == nil {
+		return nil
+	}
+	return &location{URI: d.uri, Range: d.rng(decl.Position)}
+}
+
+// hover shows the first line of the declaration of the identifier at
+// offset followed by its docs.
+func (d *document) hover(offset int) *hover {
+	id := d.identAt(offset)
+	if id == nil || id.Obj == nil || id.Obj.Decl == nil {
+		return nil
+	}
+	p := id.Obj.Decl.Pos()
+	sig := string(d.src[p.Start:p.End])
+	if i := strings.IndexByte(sig, '\n'); i >= 0 {
+		sig = sig[:i]
+	}
+
+	value := "```tem\n" + strings.TrimSpace(sig) + "\n```"
+	if a, ok := id.Obj.Decl.(ast.Annotated); ok {
+		var lines []string
+		for _, doc := range a.Doc() {
+			lines = append(lines, doc.Lines()...)
+		}
+		if len(lines) > 0 {
+			value += "\n\n" + strings.Join(lines, "\n")
+		}
+	}
+	r := d.rng(id.Position)
+	return &hover{Contents: markupContent{Kind: "markdown", Value: value}, Range: &r}
+}
+
+// completion returns the type names declared in the document and in the
+// universe.
+func (d *document) completion() completionList {
+	items := []completionItem{}
+	if scope := d.ns.Scope(); scope != nil {
+		for name, obj := range scope.Objects {
+			if obj.Kind != ast.Typ {
+				continue
+			}
+			kind := completionClass
+			if t, ok := obj.Decl.(*ast.TypeDecl); ok {
+				if _, ok := t.Value.(*ast.RecordType); ok {
+					kind = completionStruct
+				}
+			}
+			items = append(items, completionItem{Label: name, Kind: kind})
+		}
+	}
+	for name, obj := range resolver.Universe.Objects {
+		if obj.Kind != ast.Builtin {
+			continue
+		}
+		items = append(items, completionItem{Label: name, Kind: completionClass, Detail: "builtin"})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return completionList{Items: items}
+}
+
+func (d *document) symbols() []documentSymbol {
+	symbols := []documentSymbol{}
+	for _, decl := range d.ns.Decls() {
+		symbols = append(symbols, d.declSymbols(decl)...)
+	}
+	return symbols
+}
+
+func (d *document) declSymbols(decl ast.Decl) []documentSymbol {
+	var names []*ast.Ident
+	var kind int
+	var detail string
+	var children []documentSymbol
+	switch decl := decl.(type) {
+	case *ast.PackageDecl:
+		names, kind = decl.Names, symbolPackage
+		if e, ok := decl.Value.(*ast.PackageExpr); ok {
+			detail = "package(" + e.Name.Value + ")"
+		}
+	case *ast.ImportDecl:
+		names, kind = decl.Names, symbolModule
+		if e, ok := decl.Value.(*ast.ImportExpr); ok {
+			detail = "import(" + e.Path.Value + ")"
+		}
+	case *ast.UsingDecl:
+		names, kind, detail = decl.Names, symbolNamespace, "using"
+	case *ast.TypeDecl:
+		names, kind = decl.Names, symbolClass
+		switch e := decl.Value.(type) {
+		case *ast.TypeExpr:
+			detail = "type(" + e.Target.Name + ")"
+		case *ast.RecordType:
+			kind, detail = symbolStruct, "record"
+			for _, f := range e.Fields {
+				children = append(children, d.declSymbols(f)...)
+			}
+		}
+	case *ast.TemplDecl:
+		names, kind, detail = decl.Names, symbolFunction, "templ"
+	case *ast.Field:
+		names, kind = decl.Names, symbolField
+		switch t := decl.Type.(type) {
+		case *ast.Ident:
+			detail = t.Name
+		case *ast.ListType:
+			if elem, ok := t.Elem.(*ast.Ident); ok {
+				detail = "[]" + elem.Name
+			}
+		}
+	}
+
+	var symbols []documentSymbol
+	for _, name := range names {
+		symbols = append(symbols, documentSymbol{
+			Name:           name.Name,
+			Detail:         detail,
+			Kind:           kind,
+			Range:          d.rng(decl.Pos()),
+			SelectionRange: d.rng(name.Position),
+			Children:       children,
+		})
+	}
+	return symbols
+}
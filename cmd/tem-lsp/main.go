@@ -0,0 +1,33 @@
+// Tem-lsp is a language server of the tem language. It speaks the
+// language server protocol over stdio.
+//
+// Usage:
+//
+//	tem-lsp
+//
+// The documents are synchronized in full. The server publishes the
+// diagnostics of the open documents on each change and answers the
+// requests for:
+//
+//	textDocument/documentSymbol  the package, the imports, the usings, the
+//	                             types with the fields of the records and
+//	                             the templs
+//	textDocument/definition      the declaration of a name, like the type
+//	                             of type(X) or of a field
+//	textDocument/hover           the declaration of a name and its docs
+//	textDocument/completion      the type names of the document and the
+//	                             builtin types
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	status, err := newServer(os.Stdin, os.Stdout).run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tem-lsp: %s\n", err)
+	}
+	os.Exit(status)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// server serves one client until the exit notification or the end of the
+// input.
+type server struct {
+	conn *conn
+	docs map[string]*document
+	// shutdown is set by the shutdown request, the exit status is 1 if
+	// the client exits without it
+	shutdown bool
+}
+
+func newServer(r io.Reader, w io.Writer) *server {
+	return &server{conn: newConn(r, w), docs: map[string]*document{}}
+}
+
+// run serves the client and returns the exit status.
+func (s *server) run() (int, error) {
+	for {
+		body, err := s.conn.read()
+		if errors.Is(err, io.EOF) {
+			return 1, nil
+		}
+		if err != nil {
+			return 1, err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			err := s.conn.reply(json.RawMessage("null"), nil, &responseError{Code: codeParseError, Message: err.Error()})
+			if err != nil {
+				return 1, err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			if s.shutdown {
+				return 0, nil
+			}
+			return 1, nil
+		}
+
+		result, rerr := s.handle(req)
+		if req.ID == nil {
+			// a notification has no response
+			continue
+		}
+		if err := s.conn.reply(req.ID, result, rerr); err != nil {
+			return 1, err
+		}
+	}
+}
+
+// handle dispatches a message to its handler.
+func (s *server) handle(req request) (any, *responseError) {
+	switch req.Method {
+	case "initialize":
+		return initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync:       syncFull,
+				DocumentSymbolProvider: true,
+				DefinitionProvider:     true,
+				HoverProvider:          true,
+				CompletionProvider:     &completionOptions{TriggerCharacters: []string{":", "("}},
+			},
+			ServerInfo: serverInfo{Name: "tem-lsp"},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		doc := params.TextDocument
+		s.update(newDocument(doc.URI, doc.Version, doc.Text))
+		return nil, nil
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok || len(params.Changes) == 0 {
+			return nil, nil
+		}
+		// the document is synchronized in full, the last change is the
+		// whole text
+		text := params.Changes[len(params.Changes)-1].Text
+		s.update(newDocument(doc.uri, params.TextDocument.Version, text))
+		return nil, nil
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		delete(s.docs, params.TextDocument.URI)
+		s.publish(publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []diagnostic{}})
+		return nil, nil
+	case "textDocument/documentSymbol":
+		var params documentSymbolParams
+		if err := unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return doc.symbols(), nil
+	case "textDocument/definition":
+		doc, offset, err := s.position(req.Params)
+		if err != nil {
+			return nil, err
+		}
+		if loc := doc.definition(offset); loc != nil {
+			return loc, nil
+		}
+		return nil, nil
+	case "textDocument/hover":
+		doc, offset, err := s.position(req.Params)
+		if err != nil {
+			return nil, err
+		}
+		if h := doc.hover(offset); h != nil {
+			return h, nil
+		}
+		return nil, nil
+	case "textDocument/completion":
+		doc, _, err := s.position(req.Params)
+		if err != nil {
+			return nil, err
+		}
+		return doc.completion(), nil
+	default:
+		return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not found: %s", req.Method)}
+	}
+}
+
+func unmarshal(params json.RawMessage, v any) *responseError {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *server) document(uri string) (*document, *responseError) {
+	doc, ok := s.docs[uri]
+	if !ok {
+		return nil, &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("document not open: %s", uri)}
+	}
+	return doc, nil
+}
+
+// position returns the document and the offset of the position of a
+// text document position request.
+func (s *server) position(raw json.RawMessage) (*document, int, *responseError) {
+	var params textDocumentPositionParams
+	if err := unmarshal(raw, &params); err != nil {
+		return nil, 0, err
+	}
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, 0, err
+	}
+	return doc, doc.offset(params.Position), nil
+}
+
+// update replaces the document of the same URI and publishes its
+// diagnostics.
+func (s *server) update(doc *document) {
+	s.docs[doc.uri] = doc
+	s.publish(publishDiagnosticsParams{URI: doc.uri, Version: doc.version, Diagnostics: doc.diagnostics()})
+}
+
+func (s *server) publish(params publishDiagnosticsParams) {
+	// a notification has no response to report a failed write to
+	_ = s.conn.notify("textDocument/publishDiagnostics", params)
+}
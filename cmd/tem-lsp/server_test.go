@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const uri = "file:///views/user.tem"
+
+const src = `p :: package("views")
+User : "a registered user"
+User :: record{ name: String; friend: Friend }
+Friend :: type(User)
+User :: templ(u: User){ <p (u.name)/> }
+`
+
+// session runs the server on the messages and returns the messages it
+// wrote and its exit status.
+func session(t *testing.T, msgs ...any) ([]map[string]any, int) {
+	t.Helper()
+	var in bytes.Buffer
+	for _, msg := range msgs {
+		body, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+
+	var out bytes.Buffer
+	status, err := newServer(&in, &out).run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var replies []map[string]any
+	c := newConn(&out, io.Discard)
+	for {
+		body, err := c.read()
+		if err == io.EOF {
+			return replies, status
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var reply map[string]any
+		if err := json.Unmarshal(body, &reply); err != nil {
+			t.Fatal(err)
+		}
+		replies = append(replies, reply)
+	}
+}
+
+func call(id int, method string, params any) map[string]any {
+	return map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params}
+}
+
+func notify(method string, params any) map[string]any {
+	return map[string]any{"jsonrpc": "2.0", "method": method, "params": params}
+}
+
+func open(text string) map[string]any {
+	return notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": uri, "languageId": "tem", "version": 1, "text": text},
+	})
+}
+
+func at(line, char int) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"position":     map[string]any{"line": line, "character": char},
+	}
+}
+
+// result returns the result of the reply to the request id.
+func result(t *testing.T, replies []map[string]any, id int) any {
+	t.Helper()
+	for _, r := range replies {
+		if rid, ok := r["id"].(float64); ok && int(rid) == id {
+			if r["error"] != nil {
+				t.Fatalf("request %d failed: %v", id, r["error"])
+			}
+			return r["result"]
+		}
+	}
+	t.Fatalf("no reply to request %d", id)
+	return nil
+}
+
+// toJSON round trips v to compare it with the decoded replies.
+func toJSON(t *testing.T, v any) any {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res any
+	if err := json.Unmarshal(b, &res); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestLifecycle(t *testing.T) {
+	replies, status := session(t,
+		call(1, "initialize", map[string]any{}),
+		notify("initialized", map[string]any{}),
+		call(2, "unknown/method", nil),
+		call(3, "shutdown", nil),
+		notify("exit", nil),
+	)
+	if status != 0 {
+		t.Errorf("expected status 0 got %d", status)
+	}
+	caps := result(t, replies, 1).(map[string]any)["capabilities"].(map[string]any)
+	for _, name := range []string{"documentSymbolProvider", "definitionProvider", "hoverProvider"} {
+		if caps[name] != true {
+			t.Errorf("expected %s in %v", name, caps)
+		}
+	}
+	if err := replies[1]["error"].(map[string]any); err["code"] != float64(codeMethodNotFound) {
+		t.Errorf("expected method not found got %v", err)
+	}
+
+	if _, status := session(t, notify("exit", nil)); status != 1 {
+		t.Errorf("expected status 1 without shutdown got %d", status)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	replies, _ := session(t,
+		open("p :: package(\"views\")\nUser :: record{ name: Persön; age: Int }\n"),
+		notify("textDocument/didChange", map[string]any{
+			"textDocument":   map[string]any{"uri": uri, "version": 2},
+			"contentChanges": []any{map[string]any{"text": src}},
+		}),
+		notify("textDocument/didClose", map[string]any{"textDocument": map[string]any{"uri": uri}}),
+	)
+	if len(replies) != 3 {
+		t.Fatalf("expected 3 notifications got %v", replies)
+	}
+
+	expected := toJSON(t, publishDiagnosticsParams{
+		URI:     uri,
+		Version: 1,
+		Diagnostics: []diagnostic{{
+			// the range counts UTF-16 code units
+			Range:    rng{Start: position{Line: 1, Character: 22}, End: position{Line: 1, Character: 28}},
+			Severity: severityError,
+			Code:     "resolve",
+			Source:   "tem",
+			Message:  "undeclared name: Persön",
+		}},
+	})
+	if diff := cmp.Diff(expected, replies[0]["params"]); diff != "" {
+		t.Error(diff)
+	}
+	for _, r := range replies[1:] {
+		if diags := r["params"].(map[string]any)["diagnostics"].([]any); len(diags) != 0 {
+			t.Errorf("expected no diagnostics got %v", diags)
+		}
+	}
+}
+
+func TestNavigation(t *testing.T) {
+	replies, _ := session(t,
+		open(src),
+		// Friend :: type(User)
+		call(1, "textDocument/definition", at(3, 16)),
+		// friend: Friend
+		call(2, "textDocument/definition", at(2, 40)),
+		// a builtin has no declaration
+		call(3, "textDocument/definition", at(2, 24)),
+		call(4, "textDocument/hover", at(3, 16)),
+		call(5, "textDocument/completion", at(3, 15)),
+	)
+
+	userType := toJSON(t, location{URI: uri, Range: rng{
+		Start: position{Line: 2, Character: 0},
+		End:   position{Line: 2, Character: 4},
+	}})
+	if diff := cmp.Diff(userType, result(t, replies, 1)); diff != "" {
+		t.Error(diff)
+	}
+	friend := toJSON(t, location{URI: uri, Range: rng{
+		Start: position{Line: 3, Character: 0},
+		End:   position{Line: 3, Character: 6},
+	}})
+	if diff := cmp.Diff(friend, result(t, replies, 2)); diff != "" {
+		t.Error(diff)
+	}
+	if res := result(t, replies, 3); res != nil {
+		t.Errorf("expected no definition of a builtin got %v", res)
+	}
+
+	contents := result(t, replies, 4).(map[string]any)["contents"].(map[string]any)
+	value := "```tem\nUser :: record{ name: String; friend: Friend }\n```\n\na registered user"
+	if contents["value"] != value {
+		t.Errorf("expected hover %q got %q", value, contents["value"])
+	}
+
+	var labels []string
+	for _, item := range result(t, replies, 5).(map[string]any)["items"].([]any) {
+		labels = append(labels, item.(map[string]any)["label"].(string))
+	}
+	for _, name := range []string{"Friend", "String", "User"} {
+		if !strings.Contains(strings.Join(labels, " "), name) {
+			t.Errorf("expected %s in the completions %v", name, labels)
+		}
+	}
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	replies, _ := session(t,
+		open(src),
+		call(1, "textDocument/documentSymbol", map[string]any{"textDocument": map[string]any{"uri": uri}}),
+	)
+
+	type symbol struct {
+		name, detail string
+		kind         int
+		children     []symbol
+	}
+	var convert func(v any) []symbol
+	convert = func(v any) []symbol {
+		var symbols []symbol
+		list, _ := v.([]any)
+		for _, s := range list {
+			s := s.(map[string]any)
+			detail, _ := s["detail"].(string)
+			symbols = append(symbols, symbol{
+				name:     s["name"].(string),
+				detail:   detail,
+				kind:     int(s["kind"].(float64)),
+				children: convert(s["children"]),
+			})
+		}
+		return symbols
+	}
+
+	expected := []symbol{
+		{name: "p", detail: `package("views")`, kind: symbolPackage},
+		{name: "User", detail: "record", kind: symbolStruct, children: []symbol{
+			{name: "name", detail: "String", kind: symbolField},
+			{name: "friend", detail: "Friend", kind: symbolField},
+		}},
+		{name: "Friend", detail: "type(User)", kind: symbolClass},
+		{name: "User", detail: "templ", kind: symbolFunction},
+	}
+	got := convert(result(t, replies, 1))
+	if diff := cmp.Diff(expected, got, cmp.AllowUnexported(symbol{})); diff != "" {
+		t.Error(diff)
+	}
+}
+
+// the framing reads the body by length whatever its content
+func TestConnRead(t *testing.T) {
+	in := "Content-Length: 2\r\nContent-Type: application/vscode-jsonrpc\r\n\r\n{}Content-Length: 4\r\n\r\nnull"
+	c := newConn(bufio.NewReader(strings.NewReader(in)), io.Discard)
+	for _, expected := range []string{"{}", "null"} {
+		body, err := c.read()
+		if err != nil || string(body) != expected {
+			t.Errorf("expected %s got %s, %v", expected, body, err)
+		}
+	}
+	if _, err := c.read(); err != io.EOF {
+		t.Errorf("expected EOF got %v", err)
+	}
+}
+
+// the queries on a document being typed must not fail whatever its
+// syntax errors
+func TestIncompleteDocument(t *testing.T) {
+	for i := range src {
+		doc := newDocument(uri, 1, src[:i])
+		doc.diagnostics()
+		doc.symbols()
+		doc.completion()
+		for offset := 0; offset <= i; offset++ {
+			doc.definition(offset)
+			doc.hover(offset)
+		}
+	}
+}
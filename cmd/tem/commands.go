@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"temlang/tem/ast"
+	"temlang/tem/format"
+	"temlang/tem/gen/golang"
+	"temlang/tem/parser"
+	"temlang/tem/token"
+	"temlang/tem/tokenizer"
+	"temlang/tem/types"
+)
+
+var tokensCmd = &command{
+	name: "tokens",
+	run: func(c *command, e *env, filename string, src []byte) bool {
+		var errs token.ErrorQueue
+		handler := func(offset int, ch string, msg string) {
+			err := token.NewError(offset, fmt.Sprintf("%s %q", msg, ch))
+			errs.Push(err.WithCode(token.CodeSyntax))
+		}
+		t := tokenizer.New(filename, src, tokenizer.SetErrorHandler(handler))
+
+		var toks []token.Token
+		for {
+			tok := t.Next()
+			toks = append(toks, tok)
+			if tok.Kind() == token.EOF {
+				break
+			}
+		}
+
+		ns := ast.New(filename, "")
+		ns.SetLines(t.Lines())
+		for _, tok := range toks {
+			pos := ns.LineCol(tok.Start())
+			fmt.Fprintf(e.stdout, "%s:%d:%d\t%s\t%q\n", filename, pos.Line, pos.Col, tok.Kind(), tok.Text())
+		}
+		return e.report(filename, src, t.Lines(), &errs)
+	},
+}
+
+var parseCmd = &command{
+	name: "parse",
+	flags: func(c *command, fs *flag.FlagSet) {
+		fs.BoolVar(&c.json, "json", false, "print the syntax tree as JSON")
+		fs.BoolVar(&c.comments, "comments", false, "keep the comments in the syntax tree")
+	},
+	run: func(c *command, e *env, filename string, src []byte) bool {
+		var opts []parser.Option
+		if c.comments {
+			opts = append(opts, parser.ParseComments())
+		}
+		file, errs := parser.ParseFile(filename, src, opts...)
+		if c.json {
+			b, err := json.MarshalIndent(file, "", "  ")
+			if err != nil {
+				fmt.Fprintf(e.stderr, "tem: %s\n", err)
+				return false
+			}
+			fmt.Fprintf(e.stdout, "%s\n", b)
+		} else {
+			fmt.Fprintln(e.stdout, ast.PrintSExpr(file))
+		}
+		return e.report(filename, src, file.Lines(), errs)
+	},
+}
+
+var checkCmd = &command{
+	name: "check",
+	run: func(c *command, e *env, filename string, src []byte) bool {
+		file, errs := parser.ParseFile(filename, src)
+		if errs.Empty() {
+			types.Check(file, errs)
+		}
+		return e.report(filename, src, file.Lines(), errs)
+	},
+}
+
+var fmtCmd = &command{
+	name: "fmt",
+	flags: func(c *command, fs *flag.FlagSet) {
+		fs.BoolVar(&c.write, "w", false, "write the result to the file instead of stdout")
+		fs.BoolVar(&c.diff, "d", false, "print the diff of the formatting instead of the result")
+	},
+	run: func(c *command, e *env, filename string, src []byte) bool {
+		file, errs := parser.ParseFile(filename, src, parser.ParseComments())
+		if !errs.Empty() {
+			return e.report(filename, src, file.Lines(), errs)
+		}
+
+		var buf bytes.Buffer
+		if err := format.Node(&buf, file, src); err != nil {
+			fmt.Fprintf(e.stderr, "tem: %s\n", err)
+			return false
+		}
+		res := buf.Bytes()
+
+		if c.diff {
+			if !bytes.Equal(src, res) {
+				fmt.Fprintf(e.stdout, "diff %s %s.formatted\n", filename, filename)
+				fmt.Fprint(e.stdout, diff(filename, string(src), string(res)))
+			}
+		}
+		if c.write {
+			if bytes.Equal(src, res) {
+				return true
+			}
+			if err := os.WriteFile(filename, res, 0o644); err != nil {
+				fmt.Fprintf(e.stderr, "tem: %s\n", err)
+				return false
+			}
+		}
+		if !c.diff && !c.write {
+			_, err := e.stdout.Write(res)
+			return err == nil
+		}
+		return true
+	},
+}
+
+var genCmd = &command{
+	name: "gen",
+	flags: func(c *command, fs *flag.FlagSet) {
+		fs.BoolVar(&c.stdout, "stdout", false, "print the Go source instead of writing file.tem.go")
+	},
+	run: func(c *command, e *env, filename string, src []byte) bool {
+		file, errs := parser.ParseFile(filename, src)
+		if !errs.Empty() {
+			return e.report(filename, src, file.Lines(), errs)
+		}
+
+		var buf bytes.Buffer
+		if err := golang.Generate(&buf, file, errs); err != nil {
+			if errs.Empty() {
+				fmt.Fprintf(e.stderr, "tem: %s: %s\n", filename, err)
+			}
+			e.report(filename, src, file.Lines(), errs)
+			return false
+		}
+
+		if c.stdout {
+			_, err := e.stdout.Write(buf.Bytes())
+			return err == nil
+		}
+		out := strings.TrimSuffix(filename, ".tem") + ".tem.go"
+		if err := os.WriteFile(out, buf.Bytes(), 0o644); err != nil {
+			fmt.Fprintf(e.stderr, "tem: %s\n", err)
+			return false
+		}
+		return true
+	},
+}
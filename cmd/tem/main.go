@@ -0,0 +1,183 @@
+// Tem is the command line driver of the tem language.
+//
+// Usage:
+//
+//	tem <command> [flags] [path ...]
+//
+// The commands are:
+//
+//	tokens  print the tokens of the files
+//	parse   print the syntax tree of the files as an s-expression or JSON
+//	check   report the errors of the files
+//	fmt     format the files
+//	gen     generate the Go source of the files
+//
+// A path is a .tem file or a directory whose .tem files are used. The
+// diagnostics are printed as file:line:col: severity[code]: msg followed
+// by the source line in error, and the exit status is 1 if any file has
+// errors.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"temlang/tem/ast"
+	"temlang/tem/token"
+)
+
+const usage = `usage: tem <command> [flags] [path ...]
+
+commands:
+	tokens  print the tokens of the files
+	parse   print the syntax tree of the files
+	check   report the errors of the files
+	fmt     format the files
+	gen     generate the Go source of the files
+
+run 'tem <command> -h' for the flags of a command
+`
+
+// exit statuses
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// command is a command of tem. The commands are copied for each
+// invocation, the flags set the fields of the copy.
+type command struct {
+	name string
+	// flags registers the flags of the command
+	flags func(c *command, fs *flag.FlagSet)
+	// run processes one file and reports whether it has no error
+	run func(c *command, env *env, filename string, src []byte) bool
+
+	// parse
+	json     bool
+	comments bool
+	// fmt
+	write bool
+	diff  bool
+	// gen
+	stdout bool
+}
+
+var commands = []*command{
+	tokensCmd,
+	parseCmd,
+	checkCmd,
+	fmtCmd,
+	genCmd,
+}
+
+// env is the environment a command runs in.
+type env struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	var cmd *command
+	for _, c := range commands {
+		if c.name == args[0] {
+			cmd = new(command)
+			*cmd = *c
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(stderr, "tem: unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet("tem "+cmd.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	if cmd.flags != nil {
+		cmd.flags(cmd, fs)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+
+	files, err := collect(fs.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "tem: %s\n", err)
+		return exitError
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(stderr, "tem %s: no .tem files\n", cmd.name)
+		return exitUsage
+	}
+
+	e := &env{stdout: stdout, stderr: stderr}
+	status := exitOK
+	for _, filename := range files {
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			fmt.Fprintf(stderr, "tem: %s\n", err)
+			status = exitError
+			continue
+		}
+		if !cmd.run(cmd, e, filename, src) {
+			status = exitError
+		}
+	}
+	return status
+}
+
+// collect returns the .tem files of the paths in the order of the paths,
+// the files of a directory are sorted.
+func collect(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		var dir []string
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".tem") {
+				dir = append(dir, filepath.Join(path, entry.Name()))
+			}
+		}
+		sort.Strings(dir)
+		files = append(files, dir...)
+	}
+	return files, nil
+}
+
+// report prints the errors as diagnostics with their source lines and
+// reports whether there was none. lines are the line offsets of src.
+func (e *env) report(filename string, src []byte, lines []int, errs *token.ErrorQueue) bool {
+	ok := errs.Empty()
+	ns := ast.New(filename, "")
+	ns.SetLines(lines)
+	for !errs.Empty() {
+		err, _ := errs.Pop()
+		ns.Diagnostic(err).Render(e.stderr, src)
+	}
+	return ok
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const valid = `p :: package("views")
+User :: record{ name: String }
+User :: templ(u: User){ <p (u.name)/> }
+`
+
+const invalid = `p :: package("views")
+User :: record{ name: Person }
+`
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func runTem(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	status := run(args, &stdout, &stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+func TestRunUsage(t *testing.T) {
+	if status, _, _ := runTem(); status != exitUsage {
+		t.Errorf("expected status %d got %d", exitUsage, status)
+	}
+	if status, _, _ := runTem("unknown"); status != exitUsage {
+		t.Errorf("expected status %d got %d", exitUsage, status)
+	}
+}
+
+func TestRunCheck(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"valid.tem":   valid,
+		"invalid.tem": invalid,
+		"other.txt":   "not a tem file",
+	})
+
+	status, _, stderr := runTem("check", filepath.Join(dir, "valid.tem"))
+	if status != exitOK || stderr != "" {
+		t.Errorf("expected valid.tem to check got %d %q", status, stderr)
+	}
+
+	status, _, stderr = runTem("check", dir)
+	if status != exitError {
+		t.Errorf("expected status %d got %d", exitError, status)
+	}
+	want := filepath.Join(dir, "invalid.tem") + ":2:23: error[resolve]: undeclared name: Person\n" +
+		" 2 | User :: record{ name: Person }\n" +
+		"   |                       ^^^^^^\n"
+	if stderr != want {
+		t.Errorf("expected diagnostic %q got %q", want, stderr)
+	}
+}
+
+func TestRunTokens(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"valid.tem": valid})
+	filename := filepath.Join(dir, "valid.tem")
+
+	status, stdout, _ := runTem("tokens", filename)
+	if status != exitOK {
+		t.Fatalf("expected status %d got %d", exitOK, status)
+	}
+	first := strings.SplitN(stdout, "\n", 2)[0]
+	if want := filename + ":1:1\tident\t\"p\""; first != want {
+		t.Errorf("expected first token %q got %q", want, first)
+	}
+}
+
+func TestRunParse(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"valid.tem": valid})
+	filename := filepath.Join(dir, "valid.tem")
+
+	status, stdout, _ := runTem("parse", filename)
+	if status != exitOK || !strings.Contains(stdout, "(record_") {
+		t.Errorf("expected an s-expression got %d\n%s", status, stdout)
+	}
+
+	status, stdout, _ = runTem("parse", "-json", filename)
+	if status != exitOK {
+		t.Fatalf("expected status %d got %d", exitOK, status)
+	}
+	var tree struct {
+		Node  string
+		Decls []map[string]any
+	}
+	if err := json.Unmarshal([]byte(stdout), &tree); err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.Decls) != 3 || tree.Decls[1]["node"] != "TypeDecl" {
+		t.Errorf("unexpected JSON tree\n%s", stdout)
+	}
+}
+
+// the flags of an invocation do not change the concurrent invocations
+func TestRunConcurrent(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"valid.tem": valid})
+	filename := filepath.Join(dir, "valid.tem")
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				_, stdout, _ := runTem("parse", "-json", filename)
+				if !strings.HasPrefix(stdout, "{") {
+					t.Errorf("expected JSON got\n%s", stdout)
+				}
+				return
+			}
+			_, stdout, _ := runTem("parse", filename)
+			if strings.HasPrefix(stdout, "{") {
+				t.Errorf("expected an s-expression got\n%s", stdout)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRunGen(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"valid.tem": valid})
+
+	if status, _, stderr := runTem("gen", dir); status != exitOK {
+		t.Fatalf("expected status %d got %d %s", exitOK, status, stderr)
+	}
+	src, err := os.ReadFile(filepath.Join(dir, "valid.tem.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(src, []byte("func RenderUser(w io.Writer, u User) error")) {
+		t.Errorf("unexpected generated source\n%s", src)
+	}
+}
+
+func TestRunFmt(t *testing.T) {
+	unformatted := "p :: package(\"views\")\nA :: type(String)\nLong :: type(Int)\n"
+	formatted := "p    :: package(\"views\")\nA    :: type(String)\nLong :: type(Int)\n"
+	dir := writeFiles(t, map[string]string{"a.tem": unformatted})
+	filename := filepath.Join(dir, "a.tem")
+
+	status, stdout, _ := runTem("fmt", "-d", filename)
+	if status != exitOK || !strings.Contains(stdout, "\n-A :: type(String)\n") || !strings.Contains(stdout, "\n+A    :: type(String)\n") {
+		t.Errorf("unexpected diff %d\n%s", status, stdout)
+	}
+
+	if status, _, stderr := runTem("fmt", "-w", filename); status != exitOK {
+		t.Fatalf("expected status %d got %d %s", exitOK, status, stderr)
+	}
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(src) != formatted {
+		t.Errorf("expected the file to be formatted got\n%s", src)
+	}
+
+	if _, stdout, _ := runTem("fmt", "-d", filename); stdout != "" {
+		t.Errorf("expected no diff got\n%s", stdout)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	_ "embed"
 	"fmt"
 	"log"
+	"os"
 	"temlang/tem/ast"
 	"temlang/tem/parser"
 )
@@ -17,15 +18,15 @@ var semicolon []byte
 //go:embed template.tem
 var tmpl []byte
 
-//go:embed def_semicolon.tem
+//go:embed template_semicolon.tem
 var tmplSemicolon []byte
 
 func main() {
 	srcs := map[string][]byte{
-		"def.tem":           def,
-		"def_semicolon.tem": semicolon,
-		// "template.tem":       tmpl,
-		"template_semicolon": tmplSemicolon,
+		"def.tem":                def,
+		"def_semicolon.tem":      semicolon,
+		"template.tem":           tmpl,
+		"template_semicolon.tem": tmplSemicolon,
 	}
 	for name, src := range srcs {
 		str := getString(name, src)
@@ -45,7 +46,7 @@ func parse(filename string, src []byte) string {
 		if !ok {
 			break
 		}
-		fmt.Printf("%s %d\n", err.Message(), err.Offset())
+		file.Diagnostic(err).Render(os.Stdout, src)
 	}
 	str := ast.PrintSExpr(file)
 	return str
@@ -3,14 +3,30 @@ package main
 import (
 	_ "embed"
 	"strings"
+	"temlang/tem/parser"
+	"temlang/tem/types"
 	"testing"
 )
 
+// check reports the errors of the parser and of the checker on src.
+func check(t *testing.T, name string, src []byte) {
+	t.Helper()
+	file, errs := parser.ParseFile(name, src)
+	if errs.Empty() {
+		types.Check(file, errs)
+	}
+	for !errs.Empty() {
+		err, _ := errs.Pop()
+		t.Error(file.Diagnostic(err))
+	}
+}
+
 func TestDemoDef(t *testing.T) {
 	str := getString("def.tem", def)
 	if strings.Contains(str, "ERROR") {
 		t.Error("parser failed unexpectedly")
 	}
+	check(t, "def.tem", def)
 }
 
 func TestDemoSemicolon(t *testing.T) {
@@ -18,12 +34,21 @@ func TestDemoSemicolon(t *testing.T) {
 	if strings.Contains(str, "ERROR") {
 		t.Error("parser failed unexpectedly")
 	}
+	check(t, "def_semicolon.tem", semicolon)
 }
 
-// func TestDemoTmpl(t *testing.T) {
-// 	run("template.tem", tmpl)
-// }
+func TestDemoTmpl(t *testing.T) {
+	str := getString("template.tem", tmpl)
+	if strings.Contains(str, "ERROR") {
+		t.Error("parser failed unexpectedly")
+	}
+	check(t, "template.tem", tmpl)
+}
 
-// func TestDemoTmplSemicolon(t *testing.T) {
-// 	run("template_semicolon.tem", tmplSemicolon)
-// }
+func TestDemoTmplSemicolon(t *testing.T) {
+	str := getString("template_semicolon.tem", tmplSemicolon)
+	if strings.Contains(str, "ERROR") {
+		t.Error("parser failed unexpectedly")
+	}
+	check(t, "template_semicolon.tem", tmplSemicolon)
+}
@@ -67,6 +67,9 @@ func (q *Queue[T]) Peek() (T, bool) {
 	return t, true
 }
 
+// compact moves the items to the front of the queue when they fill at
+// least half of it, the space of the popped items is reused by the next
+// pushes.
 func (q *Queue[T]) compact() {
 	if q.Empty() {
 		return
@@ -84,15 +87,10 @@ func (q *Queue[T]) compact() {
 		return
 	}
 
-	var empty T
-	i := 0
-
-	for ; i < itemSize; i++ {
-		index := q.readOffset + i
-		cur := q.items[index]
-		q.items[i] = cur
-		q.items[index] = empty
-	}
-	q.writeOffset = i
+	// the items are moved to the front before clearing the tail so an
+	// item is never cleared after being moved
+	n := copy(q.items, q.items[q.readOffset:q.writeOffset])
+	clear(q.items[n:q.writeOffset])
+	q.writeOffset = n
 	q.readOffset = 0
 }
@@ -156,3 +156,51 @@ func TestCompactLeft(t *testing.T) {
 		t.Errorf("expected value %d got %d", expected, got)
 	}
 }
+
+func TestCompactWithoutPop(t *testing.T) {
+	var q Queue[int]
+
+	for i := range 20 {
+		q.Push(i)
+	}
+
+	// The queue is compacted on push even if nothing was popped
+	// the items must be kept in order
+	for i := range 20 {
+		v, ok := q.Pop()
+		if !ok {
+			t.Fatalf("pop %d should succeed", i)
+		}
+		if v != i {
+			t.Errorf("expected value %d got %d", i, v)
+		}
+	}
+}
+
+func TestCompactAfterPop(t *testing.T) {
+	var q Queue[int]
+
+	for i := range 12 {
+		q.Push(i)
+	}
+	q.Pop()
+	q.Pop()
+	// the pushes compact the queue, the items moved to the front
+	// overlap the items being moved
+	for i := 12; i < 20; i++ {
+		q.Push(i)
+	}
+
+	for i := 2; i < 20; i++ {
+		v, ok := q.Pop()
+		if !ok {
+			t.Fatalf("pop %d should succeed", i)
+		}
+		if v != i {
+			t.Errorf("expected value %d got %d", i, v)
+		}
+	}
+	if !q.Empty() {
+		t.Errorf("expected an empty queue got %d items", q.Len())
+	}
+}
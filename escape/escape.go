@@ -0,0 +1,164 @@
+// Package escape escapes the interpolations of the templs according to
+// their HTML context. It is shared by the type checker, which rejects the
+// interpolations in unsafe contexts, the interpreter and the generated
+// Go.
+//
+//	<p (u.name)/>                 Text
+//	<p title=(u.name)/>           Attr
+//	<a href=(u.site)/>            URL
+//	<script (u.code)/>            Script, unsafe
+//	<p onclick=(u.code)/>         Script, unsafe
+//	<style (u.css)/>              Style, unsafe
+//	<p style=(u.css)/>            Style, unsafe
+//
+// A value of the builtin type HTML is trusted: it is written without
+// escaping in every context. It is for the values sanitized beforehand.
+package escape
+
+import (
+	"html"
+	"strings"
+)
+
+// Context is the HTML context of an interpolation.
+type Context int
+
+const (
+	// Text is the content of an element.
+	Text Context = iota
+	// Attr is the quoted value of an attribute.
+	Attr
+	// URL is the quoted value of an attribute holding a URL.
+	URL
+	// Script is the content of a script element or the value of an event
+	// handler attribute.
+	Script
+	// Style is the content of a style element or the value of a style
+	// attribute.
+	Style
+)
+
+func (c Context) String() string {
+	switch c {
+	case Text:
+		return "text"
+	case Attr:
+		return "attribute"
+	case URL:
+		return "URL"
+	case Script:
+		return "script"
+	case Style:
+		return "style"
+	default:
+		return "invalid context"
+	}
+}
+
+// Safe reports whether a string can be escaped for c. The strings in the
+// script and the style contexts can't be escaped: they must be trusted.
+func (c Context) Safe() bool {
+	return c == Text || c == Attr || c == URL
+}
+
+// urlAttrs are the attributes holding a URL.
+var urlAttrs = map[string]bool{
+	"action": true, "background": true, "cite": true, "codebase": true,
+	"data": true, "formaction": true, "href": true, "icon": true,
+	"longdesc": true, "manifest": true, "poster": true, "src": true,
+	"usemap": true, "xmlns": true,
+}
+
+// voids are the elements without an end tag.
+var voids = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"source": true, "track": true, "wbr": true,
+}
+
+// Void reports whether element is a void element. A void element has no
+// end tag and cannot have children.
+func Void(element string) bool {
+	return voids[strings.ToLower(element)]
+}
+
+// ContextOf returns the context of an interpolation in the value of the
+// attribute attr of element, or in its content if attr is empty.
+func ContextOf(element, attr string) Context {
+	element = strings.ToLower(element)
+	attr = strings.ToLower(attr)
+	switch {
+	case attr == "":
+		switch element {
+		case "script":
+			return Script
+		case "style":
+			return Style
+		}
+		return Text
+	case strings.HasPrefix(attr, "on"):
+		return Script
+	case attr == "style":
+		return Style
+	case urlAttrs[attr]:
+		return URL
+	}
+	return Attr
+}
+
+// In returns the context of an interpolation like ContextOf for an
+// element in the content context outer. The elements in a script or a
+// style are text of the script or the style.
+func In(outer Context, element, attr string) Context {
+	if !outer.Safe() {
+		return outer
+	}
+	return ContextOf(element, attr)
+}
+
+// Escape returns s escaped for c. The values of the unsafe contexts, like
+// numbers, are escaped as text.
+func Escape(c Context, s string) string {
+	if c == URL {
+		return FilterURL(s)
+	}
+	return html.EscapeString(s)
+}
+
+// EscapeText returns the literal text s of a templ escaped for c. The
+// content of a script or a style is raw text for the browser, the text is
+// written as is.
+func EscapeText(c Context, s string) string {
+	if !c.Safe() {
+		return s
+	}
+	return html.EscapeString(s)
+}
+
+// Unsafe replaces the URLs with a scheme that can run code.
+const Unsafe = "#ZtemZ"
+
+// safeSchemes are the schemes of the URLs written as is.
+var safeSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+	"tel":    true,
+}
+
+// FilterURL returns s escaped for an attribute value or Unsafe escaped if
+// s has a scheme other than http, https, mailto and tel, like
+// javascript:. A relative URL is safe.
+func FilterURL(s string) string {
+	// the browsers ignore the spaces and the control chars before the
+	// scheme and the tabs and the newlines within
+	trimmed := strings.TrimLeftFunc(s, func(r rune) bool { return r <= ' ' })
+	trimmed = strings.NewReplacer("\t", "", "\n", "", "\r", "").Replace(trimmed)
+	if i := strings.IndexAny(trimmed, ":/?#"); i >= 0 && trimmed[i] == ':' {
+		scheme := strings.ToLower(trimmed[:i])
+		if !safeSchemes[scheme] {
+			s = Unsafe
+		}
+	}
+	return html.EscapeString(s)
+}
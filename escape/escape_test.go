@@ -0,0 +1,68 @@
+package escape_test
+
+import (
+	"temlang/tem/escape"
+	"testing"
+)
+
+func TestContextOf(t *testing.T) {
+	testcases := []struct {
+		element, attr string
+		expected      escape.Context
+	}{
+		{"p", "", escape.Text},
+		{"script", "", escape.Script},
+		{"STYLE", "", escape.Style},
+		{"p", "title", escape.Attr},
+		{"a", "href", escape.URL},
+		{"img", "SRC", escape.URL},
+		{"p", "onclick", escape.Script},
+		{"p", "style", escape.Style},
+	}
+	for _, tc := range testcases {
+		if got := escape.ContextOf(tc.element, tc.attr); got != tc.expected {
+			t.Errorf("ContextOf(%s, %s) = %s, expected %s", tc.element, tc.attr, got, tc.expected)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	testcases := []struct {
+		context  escape.Context
+		s        string
+		expected string
+	}{
+		{escape.Text, `<b>"Tom" & 'Jerry'</b>`, "&lt;b&gt;&#34;Tom&#34; &amp; &#39;Jerry&#39;&lt;/b&gt;"},
+		{escape.Attr, `" onload="x`, "&#34; onload=&#34;x"},
+		{escape.URL, "https://example.com/?a=1&b=2", "https://example.com/?a=1&amp;b=2"},
+		{escape.URL, "MAILTO:ada@example.com", "MAILTO:ada@example.com"},
+		{escape.URL, "/users/1#top", "/users/1#top"},
+		{escape.URL, "users?next=javascript:x", "users?next=javascript:x"},
+		{escape.URL, "javascript:alert(1)", escape.Unsafe},
+		{escape.URL, " \tJava\nScript:alert(1)", escape.Unsafe},
+		{escape.URL, "data:text/html,<script>", escape.Unsafe},
+		{escape.Script, "36", "36"},
+	}
+	for _, tc := range testcases {
+		if got := escape.Escape(tc.context, tc.s); got != tc.expected {
+			t.Errorf("Escape(%s, %q) = %q, expected %q", tc.context, tc.s, got, tc.expected)
+		}
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	testcases := []struct {
+		context  escape.Context
+		s        string
+		expected string
+	}{
+		{escape.Text, "1 < 2", "1 &lt; 2"},
+		{escape.Script, `1 < 2 && "a"`, `1 < 2 && "a"`},
+		{escape.Style, "a > b", "a > b"},
+	}
+	for _, tc := range testcases {
+		if got := escape.EscapeText(tc.context, tc.s); got != tc.expected {
+			t.Errorf("EscapeText(%s, %q) = %q, expected %q", tc.context, tc.s, got, tc.expected)
+		}
+	}
+}
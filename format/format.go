@@ -0,0 +1,83 @@
+// Package format prints a namespace back to source in the canonical style.
+//
+// The canonical style is:
+//
+//   - one declaration per line with the colon after the names aligned in
+//     runs of single line declarations,
+//   - one field per line in a record, the field types aligned,
+//   - doc blocks with one "---" line per line of text,
+//   - a single space after each directive,
+//   - at most one blank line between declarations,
+//   - a tab per level of indentation, the closing brace of a record, a
+//     tag or a templ indented with the content.
+//
+// The comments of the source are kept. The texts of the templs are kept
+// as is with their white space, they are rendered: only the lines between
+// elements are indented.
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"temlang/tem/ast"
+	"temlang/tem/parser"
+	"temlang/tem/token"
+	"temlang/tem/tokenizer"
+)
+
+// Source formats src. The error reports the first syntax error of src,
+// nothing is formatted if src has errors.
+func Source(filename string, src []byte) ([]byte, error) {
+	ns, errs := parser.ParseFile(filename, src, parser.ParseComments())
+	if err, ok := errs.Pop(); ok {
+		pos := ns.LineCol(err.Offset())
+		return nil, fmt.Errorf("%s:%d:%d: %s", filename, pos.Line, pos.Col, err.Message())
+	}
+
+	var buf bytes.Buffer
+	if err := Node(&buf, ns, src); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Node writes the canonical source of ns to w. src is the source ns was
+// parsed from, it is scanned again for the comments unless ns was parsed
+// with the comments.
+func Node(w io.Writer, ns *ast.Namespace, src []byte) error {
+	cmts := commentTokens(ns.Comments())
+	if cmts == nil {
+		cmts = comments(ns.File(), src)
+	}
+	p := printer{ns: ns, comments: cmts, last: -1}
+	p.namespace()
+	_, err := w.Write(p.bytes())
+	return err
+}
+
+func commentTokens(groups []*ast.CommentGroup) []token.Token {
+	var cmts []token.Token
+	for _, g := range groups {
+		for _, c := range g.List {
+			cmts = append(cmts, token.NewWithText(token.Comment, c.Text, c.Start, c.End))
+		}
+	}
+	return cmts
+}
+
+// comments returns the comment tokens of src in order.
+func comments(filename string, src []byte) []token.Token {
+	var cmts []token.Token
+	noErr := func(offset int, ch string, msg string) {}
+	t := tokenizer.New(filename, src, tokenizer.SetErrorHandler(noErr))
+	for {
+		tok := t.Next()
+		switch tok.Kind() {
+		case token.EOF:
+			return cmts
+		case token.Comment:
+			cmts = append(cmts, tok)
+		}
+	}
+}
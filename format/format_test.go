@@ -0,0 +1,182 @@
+package format_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"temlang/tem/ast"
+	"temlang/tem/format"
+	"temlang/tem/interp"
+	"temlang/tem/parser"
+	"temlang/tem/token"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSourceGolden(t *testing.T) {
+	inputs, err := filepath.Glob("testdata/*.input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range inputs {
+		t.Run(filepath.Base(input), func(t *testing.T) {
+			src, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(strings.TrimSuffix(input, ".input") + ".golden")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := format.Source(input, src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(string(want), string(got)); diff != "" {
+				t.Errorf("Source(%s) mismatch (-want +got):\n%s", input, diff)
+			}
+
+			again, err := format.Source(input, got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(string(got), string(again)); diff != "" {
+				t.Errorf("Source is not idempotent (-first +second):\n%s", diff)
+			}
+		})
+	}
+}
+
+// shape returns the nodes of ns without their positions.
+func shape(ns *ast.Namespace) []string {
+	var nodes []string
+	ast.Inspect(ns, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case nil:
+		case *ast.Ident:
+			nodes = append(nodes, "ident "+n.Name)
+		case *ast.BasicLit:
+			if n.Kind == token.TextBlock {
+				nodes = append(nodes, "lit "+strings.TrimSpace(token.TextBlockText(n.Value)))
+				break
+			}
+			nodes = append(nodes, "lit "+n.Value)
+		case *ast.Text:
+			nodes = append(nodes, "text "+n.Value)
+		default:
+			nodes = append(nodes, fmt.Sprintf("%T", n))
+		}
+		return true
+	})
+	return nodes
+}
+
+func TestSourceKeepsTree(t *testing.T) {
+	files, err := filepath.Glob("../parser/testdata/*.tem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range files {
+		t.Run(filepath.Base(filename), func(t *testing.T) {
+			src, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := format.Source(filename, src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before, _ := parser.ParseFile(filename, src)
+			after, errs := parser.ParseFile(filename, got)
+			if errs.Len() != 0 {
+				t.Fatalf("formatted source does not parse\n%s", got)
+			}
+			if diff := cmp.Diff(shape(before), shape(after)); diff != "" {
+				t.Errorf("formatting changed the tree (-before +after):\n%s", diff)
+			}
+		})
+	}
+}
+
+// render returns the HTML of the templ of src rendered with data.
+func render(t *testing.T, src []byte, data any) string {
+	t.Helper()
+	ns, errs := parser.ParseFile("test.tem", src)
+	if !errs.Empty() {
+		err, _ := errs.Pop()
+		t.Fatalf("ParseFile failed unexpectedly: %s\n%s", err, src)
+	}
+	in, err := interp.New(ns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range ns.Decls() {
+		if d, ok := d.(*ast.TemplDecl); ok {
+			var b strings.Builder
+			if err := in.Render(&b, d, data); err != nil {
+				t.Fatal(err)
+			}
+			return b.String()
+		}
+	}
+	t.Fatal("no templ")
+	return ""
+}
+
+// the texts are rendered as is, formatting does not change the HTML
+func TestSourceKeepsRendering(t *testing.T) {
+	srcs := []string{
+		`p :: package("m")
+User :: record{ name: String; email: String }
+User :: templ(u: User){
+<p
+   Hello, (u.name)!
+       Your email is (u.email).
+   />
+}
+`,
+		`p :: package("m")
+User :: record{ name: String; email: String }
+User :: templ(u: User){
+  <div
+     text before
+     <br/>
+     after  (u.name)  (u.email)
+     line
+     <i x/> (u.name)
+     <b/>
+     />
+  }
+`,
+		`p :: package("m")
+User :: record{ name: String; email: String }
+User :: templ(u: User){ <p a  b /> <pre
+  1
+    2
+/> }
+`,
+	}
+	data := map[string]any{"name": "Ada", "email": "ada@example.com"}
+	for i, src := range srcs {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			got, err := format.Source("test.tem", []byte(src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			before, after := render(t, []byte(src), data), render(t, got, data)
+			if before != after {
+				t.Errorf("formatting changed the HTML\n%q\n%q\nformatted:\n%s", before, after, got)
+			}
+		})
+	}
+}
+
+func TestSourceError(t *testing.T) {
+	if _, err := format.Source("test.tem", []byte(`p :: package(`)); err == nil {
+		t.Error("Source succeeded unexpectedly")
+	}
+}
@@ -0,0 +1,521 @@
+package format
+
+import (
+	"math"
+	"strings"
+	"temlang/tem/ast"
+	"temlang/tem/token"
+	"unicode/utf8"
+)
+
+type printer struct {
+	ns       *ast.Namespace
+	lines    []string
+	comments []token.Token
+	// last is the end offset of the last printed node or comment
+	last int
+}
+
+func (p *printer) bytes() []byte {
+	if len(p.lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(p.lines, "\n") + "\n")
+}
+
+func (p *printer) line(indent int, s string) {
+	p.lines = append(p.lines, strings.Repeat("\t", indent)+s)
+}
+
+func (p *printer) lineOf(offset int) int {
+	return p.ns.LineCol(offset).Line
+}
+
+// space prints a blank line if the source has one between the last
+// printed node and offset.
+func (p *printer) space(offset int) {
+	if p.last < 0 || len(p.lines) == 0 || p.lines[len(p.lines)-1] == "" {
+		return
+	}
+	if p.lineOf(offset)-p.lineOf(p.last) > 1 {
+		p.lines = append(p.lines, "")
+	}
+}
+
+// flush prints the comments before offset. A comment on the line of the
+// last printed node stays at the end of that line.
+func (p *printer) flush(offset, indent int) {
+	for len(p.comments) > 0 && p.comments[0].Start() < offset {
+		c := p.comments[0]
+		p.comments = p.comments[1:]
+
+		if p.last >= 0 && len(p.lines) > 0 && p.lineOf(c.Start()) == p.lineOf(p.last) {
+			p.lines[len(p.lines)-1] += " " + c.Text()
+		} else {
+			p.space(c.Start())
+			p.line(indent, c.Text())
+		}
+		p.last = c.End()
+	}
+}
+
+func width(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+func pad(s string, w int) string {
+	return s + strings.Repeat(" ", w-width(s))
+}
+
+func identsString(idents []*ast.Ident) string {
+	names := make([]string, 0, len(idents))
+	for _, ident := range idents {
+		names = append(names, ident.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// sections splits nodes into the runs aligned together. A run is broken
+// by a blank line, a comment or a node spanning several lines.
+func (p *printer) sections(nodes []ast.Node, multiline func(ast.Node) bool) [][]ast.Node {
+	var sections [][]ast.Node
+	for i, n := range nodes {
+		if i > 0 {
+			prev := nodes[i-1]
+			if !multiline(prev) && !multiline(n) &&
+				p.lineOf(n.Pos().Start)-p.lineOf(prev.Pos().End) <= 1 &&
+				!p.hasCommentBetween(prev.Pos().End, n.Pos().Start) {
+				last := len(sections) - 1
+				sections[last] = append(sections[last], n)
+				continue
+			}
+		}
+		sections = append(sections, []ast.Node{n})
+	}
+	return sections
+}
+
+func (p *printer) hasCommentBetween(start, end int) bool {
+	for _, c := range p.comments {
+		if c.Start() >= end {
+			return false
+		}
+		if c.Start() >= start {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *printer) namespace() {
+	decls := p.ns.Decls()
+	nodes := make([]ast.Node, 0, len(decls))
+	for _, d := range decls {
+		nodes = append(nodes, d)
+	}
+
+	for _, section := range p.sections(nodes, multilineDecl) {
+		w := 0
+		for _, n := range section {
+			w = max(w, width(identsString(declNames(n))))
+		}
+		for _, n := range section {
+			p.flush(n.Pos().Start, 0)
+			p.space(n.Pos().Start)
+			// a comment on the first line of a declaration stays there
+			p.last = n.Pos().Start
+			p.decl(n.(ast.Decl), w)
+			p.last = n.Pos().End
+		}
+	}
+	p.flush(math.MaxInt, 0)
+}
+
+func declNames(n ast.Node) []*ast.Ident {
+	switch d := n.(type) {
+	case *ast.PackageDecl:
+		return d.Names
+	case *ast.ImportDecl:
+		return d.Names
+	case *ast.UsingDecl:
+		return d.Names
+	case *ast.TypeDecl:
+		return d.Names
+	case *ast.TemplDecl:
+		return d.Names
+	case *ast.Field:
+		return d.Names
+	case *ast.Doc:
+		return d.Names
+	case *ast.Tag:
+		return d.Names
+	}
+	return nil
+}
+
+func multilineDecl(n ast.Node) bool {
+	switch d := n.(type) {
+	case *ast.TypeDecl:
+		r, ok := d.Value.(*ast.RecordType)
+		return ok && len(r.Fields) > 0
+	case *ast.TemplDecl:
+		return true
+	case *ast.Doc:
+		return multilineDoc(d)
+	case *ast.Tag:
+		return multilineTag(d)
+	}
+	return false
+}
+
+func multilineDoc(d *ast.Doc) bool {
+	return len(d.Text) != 1 || d.Text[0].Kind == token.TextBlock
+}
+
+func multilineTag(t *ast.Tag) bool {
+	for _, attr := range t.Attrs {
+		if lit, ok := attr.Value.(*ast.BasicLit); ok && lit.Kind == token.TextBlock {
+			return true
+		}
+	}
+	return false
+}
+
+// head returns the names and the type annotation of a declaration, the
+// names padded to w.
+func head(names []*ast.Ident, annot *ast.Ident, w int) string {
+	s := pad(identsString(names), w)
+	if annot == nil {
+		return s + " :: "
+	}
+	return s + " : " + annot.Name + " : "
+}
+
+func directives(dirs []*ast.Ident) string {
+	var b strings.Builder
+	for _, d := range dirs {
+		b.WriteString("#" + d.Name + " ")
+	}
+	return b.String()
+}
+
+func (p *printer) decl(d ast.Decl, w int) {
+	switch d := d.(type) {
+	case *ast.PackageDecl:
+		p.line(0, head(d.Names, d.Type, w)+directives(d.Directives)+expr(d.Value))
+	case *ast.ImportDecl:
+		p.line(0, head(d.Names, d.Type, w)+directives(d.Directives)+expr(d.Value))
+	case *ast.UsingDecl:
+		p.line(0, head(d.Names, d.Type, w)+directives(d.Directives)+expr(d.Value))
+	case *ast.TypeDecl:
+		h := head(d.Names, d.Type, w) + directives(d.Directives)
+		if r, ok := d.Value.(*ast.RecordType); ok {
+			p.record(h, r, 0)
+			return
+		}
+		p.line(0, h+expr(d.Value))
+	case *ast.TemplDecl:
+		h := head(d.Names, d.Type, w) + directives(d.Directives)
+		p.templ(h, d.Value.(*ast.TemplLit))
+	case *ast.Field:
+		p.line(0, pad(identsString(d.Names), w)+" : "+expr(d.Type))
+	case *ast.Doc:
+		p.doc(pad(identsString(d.Names), w)+" : ", d, 0)
+	case *ast.Tag:
+		p.tag(pad(identsString(d.Names), w)+" : ", d, 0)
+	}
+}
+
+func expr(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.BasicLit:
+		return e.Value
+	case *ast.SelectorExpr:
+		return expr(e.X) + "." + e.Sel.Name
+	case *ast.ParenExpr:
+		return "(" + expr(e.X) + ")"
+	case *ast.ListType:
+		return "[]" + expr(e.Elem)
+	case *ast.CallExpr:
+		args := make([]string, 0, len(e.Args))
+		for _, arg := range e.Args {
+			args = append(args, expr(arg))
+		}
+		return expr(e.Fun) + "(" + strings.Join(args, ", ") + ")"
+	case *ast.PackageExpr:
+		return "package(" + e.Name.Value + ")"
+	case *ast.ImportExpr:
+		return "import(" + e.Path.Value + ")"
+	case *ast.UsingExpr:
+		return "using(" + expr(e.Target) + ")"
+	case *ast.TypeExpr:
+		return "type(" + e.Target.Name + ")"
+	case *ast.RecordType:
+		return "record{}"
+	}
+	return ""
+}
+
+// docLine returns the text of a text block in the canonical form.
+func docLine(lit *ast.BasicLit) string {
+	text := strings.TrimSpace(token.TextBlockText(lit.Value))
+	if text == "" {
+		return "---"
+	}
+	return "--- " + text
+}
+
+// doc prints a doc whose first line starts with h. The lines of a doc
+// block are aligned with the first one.
+func (p *printer) doc(h string, d *ast.Doc, indent int) {
+	if !multilineDoc(d) {
+		p.line(indent, h+d.Text[0].Value)
+		return
+	}
+	cont := strings.Repeat(" ", width(h))
+	for i, lit := range d.Text {
+		var line string
+		if lit.Kind == token.String {
+			line = lit.Value
+		} else {
+			line = docLine(lit)
+		}
+		if i == 0 {
+			p.line(indent, h+line)
+		} else {
+			p.line(indent, cont+line)
+		}
+	}
+}
+
+func attrValue(e ast.Expr) string {
+	if lit, ok := e.(*ast.BasicLit); ok && lit.Kind == token.TextBlock {
+		return docLine(lit)
+	}
+	return expr(e)
+}
+
+func (p *printer) tag(h string, t *ast.Tag, indent int) {
+	if !multilineTag(t) {
+		attrs := make([]string, 0, len(t.Attrs))
+		for _, attr := range t.Attrs {
+			attrs = append(attrs, identsString(attr.Names)+" = "+attrValue(attr.Value))
+		}
+		p.line(indent, h+"{ "+strings.Join(attrs, "; ")+" }")
+		return
+	}
+
+	p.line(indent, h+"{")
+	w := 0
+	for _, attr := range t.Attrs {
+		w = max(w, width(identsString(attr.Names)))
+	}
+	for _, attr := range t.Attrs {
+		p.line(indent+1, pad(identsString(attr.Names), w)+" = "+attrValue(attr.Value))
+	}
+	p.line(indent+1, "}")
+}
+
+func multilineField(n ast.Node) bool {
+	switch d := n.(type) {
+	case *ast.Doc:
+		return multilineDoc(d)
+	case *ast.Tag:
+		return multilineTag(d)
+	}
+	return false
+}
+
+func (p *printer) record(h string, r *ast.RecordType, indent int) {
+	if len(r.Fields) == 0 {
+		p.flush(r.End, indent+1)
+		p.line(indent, h+"record{}")
+		return
+	}
+
+	p.line(indent, h+"record{")
+	nodes := make([]ast.Node, 0, len(r.Fields))
+	for _, f := range r.Fields {
+		nodes = append(nodes, f)
+	}
+	for _, section := range p.sections(nodes, multilineField) {
+		w := 0
+		for _, n := range section {
+			w = max(w, width(identsString(declNames(n)))+1)
+		}
+		for _, n := range section {
+			p.flush(n.Pos().Start, indent+1)
+			p.space(n.Pos().Start)
+			h := pad(identsString(declNames(n))+":", w) + " "
+			switch f := n.(type) {
+			case *ast.Field:
+				p.line(indent+1, h+expr(f.Type))
+			case *ast.Doc:
+				p.doc(h, f, indent+1)
+			case *ast.Tag:
+				p.tag(h, f, indent+1)
+			}
+			p.last = n.Pos().End
+		}
+	}
+	p.flush(r.End, indent+1)
+	p.line(indent+1, "}")
+}
+
+func (p *printer) templ(h string, t *ast.TemplLit) {
+	params := make([]string, 0, len(t.Params))
+	for _, param := range t.Params {
+		params = append(params, identsString(param.Names)+": "+expr(param.Type))
+	}
+	h += "templ(" + strings.Join(params, ", ") + ") {"
+	if len(t.Body) == 0 {
+		p.line(0, h+"}")
+		return
+	}
+	p.line(0, h)
+	p.markup(t.Body, 1)
+	p.line(1, "}")
+}
+
+// isBlock reports whether m is an element, an if or a for.
+func isBlock(m ast.Markup) bool {
+	switch m.(type) {
+	case *ast.Element, *ast.IfElement, *ast.ForElement:
+		return true
+	}
+	return false
+}
+
+// runs groups the consecutive texts and interpolations of a markup list,
+// an element is a run of its own.
+func runs(list []ast.Markup) [][]ast.Markup {
+	var rs [][]ast.Markup
+	inline := false
+	for _, m := range list {
+		if isBlock(m) {
+			rs = append(rs, []ast.Markup{m})
+			inline = false
+			continue
+		}
+		if inline {
+			rs[len(rs)-1] = append(rs[len(rs)-1], m)
+			continue
+		}
+		rs = append(rs, []ast.Markup{m})
+		inline = true
+	}
+	return rs
+}
+
+// inline returns the source of a run of texts and interpolations. The
+// texts are rendered as is, their white space is kept.
+func inline(run []ast.Markup) string {
+	var b strings.Builder
+	for _, m := range run {
+		switch m := m.(type) {
+		case *ast.Text:
+			b.WriteString(m.Value)
+		case *ast.Interp:
+			b.WriteString("(" + expr(m.X) + ")")
+		}
+	}
+	return b.String()
+}
+
+// glue joins the lines printed from n to the line before them. The white
+// space between a text and an element is part of the text, nothing can be
+// added there.
+func (p *printer) glue(n int) {
+	if n == 0 || n >= len(p.lines) {
+		return
+	}
+	p.lines[n-1] += strings.TrimLeft(p.lines[n], "\t")
+	p.lines = append(p.lines[:n], p.lines[n+1:]...)
+}
+
+// endsWithText reports whether the last item of list is a text. The
+// parser drops the white space between an element or an interpolation and
+// the element or the /> following it, a line can be broken there.
+func endsWithText(list []ast.Markup) bool {
+	if len(list) == 0 {
+		return false
+	}
+	_, ok := list[len(list)-1].(*ast.Text)
+	return ok
+}
+
+// markup prints the items of a markup list on their own lines, the texts
+// are glued to the elements around them.
+func (p *printer) markup(list []ast.Markup, indent int) {
+	prev := []ast.Markup(nil)
+	for _, run := range runs(list) {
+		n := len(p.lines)
+		switch e := run[0].(type) {
+		case *ast.Element:
+			p.element(e, indent)
+		case *ast.IfElement:
+			p.children("<if ("+expr(e.Cond)+")", e.Children, indent)
+			if e.Else != nil {
+				p.children("<else", e.Else.Children, indent)
+			}
+		case *ast.ForElement:
+			h := "<for (" + e.Value.Name
+			if e.Index != nil {
+				h = "<for (" + e.Index.Name + ", " + e.Value.Name
+			}
+			p.children(h+" in "+expr(e.X)+")", e.Children, indent)
+		default:
+			p.line(indent, inline(run))
+			// the white space at the start of a text following an
+			// element is rendered
+			if _, ok := run[0].(*ast.Text); ok && prev != nil {
+				p.glue(n)
+			}
+			prev = run
+			continue
+		}
+		if endsWithText(prev) {
+			p.glue(n)
+		}
+		prev = run
+	}
+}
+
+func (p *printer) element(e *ast.Element, indent int) {
+	var b strings.Builder
+	b.WriteString("<" + e.Name.Name)
+	for _, attr := range e.Attrs {
+		b.WriteString(" " + identsString(attr.Names) + "=")
+		if lit, ok := attr.Value.(*ast.BasicLit); ok {
+			b.WriteString(lit.Value)
+		} else {
+			b.WriteString("(" + expr(attr.Value) + ")")
+		}
+	}
+	p.children(b.String(), e.Children, indent)
+}
+
+// children prints the children of an element following its head h.
+func (p *printer) children(h string, children []ast.Markup, indent int) {
+	if len(children) == 0 {
+		p.line(indent, h+"/>")
+		return
+	}
+	rs := runs(children)
+	if len(rs) == 1 && !isBlock(rs[0][0]) {
+		if text := inline(rs[0]); !strings.Contains(text, "\n") {
+			p.line(indent, h+" "+text+"/>")
+			return
+		}
+	}
+
+	p.line(indent, h)
+	p.markup(children, indent+1)
+	if endsWithText(children) {
+		p.lines[len(p.lines)-1] += "/>"
+		return
+	}
+	p.line(indent+1, "/>")
+}
@@ -0,0 +1,583 @@
+// Package golang generates Go source from a namespace.
+//
+// A record becomes a struct, a type declaration becomes a defined type and
+// a templ becomes a function writing the escaped HTML of its parameter:
+//
+//	User :: record{ name: String }
+//	User :: templ(u: User){ <p (u.name)/> }
+//
+// generates
+//
+//	type User struct {
+//		Name string
+//	}
+//
+//	func RenderUser(w io.Writer, u User) error
+package golang
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	gotoken "go/token"
+	"html"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"temlang/tem/ast"
+	"temlang/tem/escape"
+	"temlang/tem/token"
+	"temlang/tem/types"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrInvalid is returned when the namespace has errors, the errors are
+// pushed to the error queue given to Generate.
+var ErrInvalid = errors.New("golang: invalid namespace")
+
+// Generate type checks the namespace and writes its Go source to w.
+// Nothing is written if the namespace has errors.
+func Generate(w io.Writer, ns *ast.Namespace, errs *token.ErrorQueue) error {
+	n := errs.Len()
+	info := types.Check(ns, errs)
+	if errs.Len() != n {
+		return ErrInvalid
+	}
+
+	g := generator{
+		ns:      ns,
+		info:    info,
+		errors:  errs,
+		imports: map[string]string{},
+		quals:   map[*ast.Object]string{},
+		used:    map[string]bool{},
+	}
+	g.namespace()
+	if errs.Len() != n {
+		return ErrInvalid
+	}
+
+	src, err := format.Source(g.file())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+type generator struct {
+	ns     *ast.Namespace
+	info   *types.Info
+	errors *token.ErrorQueue
+
+	pkg string
+	// imports maps the name of the import declarations to their path
+	imports map[string]string
+	// quals maps the names declared by using to their package qualifier
+	quals map[*ast.Object]string
+	// used holds the import specs of the generated file
+	used map[string]bool
+	// filterURL reports whether the generated file needs temFilterURL
+	filterURL bool
+
+	body bytes.Buffer
+}
+
+func (g *generator) error(offset int, msg string) {
+	g.errors.Push(token.NewError(offset, msg).WithCode(token.CodeGen))
+}
+
+func (g *generator) errorf(offset int, format string, args ...any) {
+	g.error(offset, fmt.Sprintf(format, args...))
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.body, format, args...)
+}
+
+// file assembles the header, the package clause and the imports with the
+// generated declarations.
+func (g *generator) file() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by tem from %s. DO NOT EDIT.\n\n", g.ns.File())
+	fmt.Fprintf(&buf, "package %s\n", g.pkg)
+
+	paths := make([]string, 0, len(g.used))
+	for path := range g.used {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	if len(paths) > 0 {
+		buf.WriteString("\nimport (\n")
+		for _, path := range paths {
+			buf.WriteString(path)
+			buf.WriteString("\n")
+		}
+		buf.WriteString(")\n")
+	}
+
+	buf.Write(g.body.Bytes())
+	if g.filterURL {
+		fmt.Fprintf(&buf, filterURLSource, escape.Unsafe)
+	}
+	return buf.Bytes()
+}
+
+// filterURLSource is the source of the filter of the URLs in attribute
+// values, like escape.FilterURL. It is generated in the files using it so
+// the generated code only depends on the standard library.
+const filterURLSource = `
+// temFilterURL returns s escaped for an attribute value or %[1]q escaped
+// if s has a scheme other than http, https, mailto and tel.
+func temFilterURL(s string) string {
+	trimmed := strings.TrimLeftFunc(s, func(r rune) bool { return r <= ' ' })
+	trimmed = strings.NewReplacer("\t", "", "\n", "", "\r", "").Replace(trimmed)
+	if i := strings.IndexAny(trimmed, ":/?#"); i >= 0 && trimmed[i] == ':' {
+		switch strings.ToLower(trimmed[:i]) {
+		case "http", "https", "mailto", "tel":
+		default:
+			s = %[1]q
+		}
+	}
+	return html.EscapeString(s)
+}
+`
+
+func (g *generator) use(path string) {
+	g.used[strconv.Quote(path)] = true
+}
+
+func (g *generator) namespace() {
+	decls := g.ns.Decls()
+
+	// the package and the imports are needed before generating the
+	// declarations
+	for _, d := range decls {
+		switch d := d.(type) {
+		case *ast.PackageDecl:
+			g.packageClause(d)
+		case *ast.ImportDecl:
+			path := g.path(d.Value.(*ast.ImportExpr).Path)
+			for _, name := range d.Names {
+				g.imports[name.Name] = path
+			}
+		case *ast.UsingDecl:
+			g.using(d)
+		}
+	}
+	if g.pkg == "" {
+		g.error(0, "missing package declaration")
+		return
+	}
+
+	for _, d := range decls {
+		switch d := d.(type) {
+		case *ast.TypeDecl:
+			g.typeDecl(d)
+		case *ast.TemplDecl:
+			g.templDecl(d)
+		case *ast.Field:
+			g.varDecl(d)
+		}
+	}
+}
+
+func (g *generator) packageClause(d *ast.PackageDecl) {
+	lit := d.Value.(*ast.PackageExpr).Name
+	name := g.path(lit)
+	if !gotoken.IsIdentifier(name) {
+		g.errorf(lit.Start, "package name %s is not a valid Go identifier", lit.Value)
+		return
+	}
+	g.pkg = name
+}
+
+// path returns the value of a string literal
+func (g *generator) path(lit *ast.BasicLit) string {
+	path, err := token.Unquote(lit.Value)
+	if err != nil {
+		return lit.Value
+	}
+	return path
+}
+
+func docText(lit *ast.BasicLit) string {
+	if lit.Kind == token.TextBlock {
+		return token.TextBlockText(lit.Value)
+	}
+	text, err := token.Unquote(lit.Value)
+	if err != nil {
+		return lit.Value
+	}
+	return text
+}
+
+// using qualifies the names declared by a using of an import or of a
+// namespace of an import with the name of the import.
+func (g *generator) using(d *ast.UsingDecl) {
+	e := d.Value.(*ast.UsingExpr).Target
+	if sel, ok := e.(*ast.SelectorExpr); ok {
+		e = sel.X
+	}
+	target, ok := e.(*ast.Ident)
+	if !ok || target.Obj == nil || target.Obj.Kind != ast.Imp {
+		return
+	}
+	for _, name := range d.Names {
+		if name.Obj != nil {
+			g.quals[name.Obj] = target.Name
+		}
+	}
+}
+
+// doc prints the docs of a declaration as a comment.
+func (g *generator) doc(d ast.Annotated) {
+	for _, doc := range d.Doc() {
+		for _, text := range doc.Text {
+			for _, line := range strings.Split(docText(text), "\n") {
+				g.printf("// %s\n", line)
+			}
+		}
+	}
+}
+
+func (g *generator) typeDecl(d *ast.TypeDecl) {
+	for _, name := range d.Names {
+		g.printf("\n")
+		g.doc(d)
+		switch e := d.Value.(type) {
+		case *ast.TypeExpr:
+			g.printf("type %s %s\n", name.Name, g.typeName(e.Target))
+		case *ast.RecordType:
+			g.printf("type %s struct {\n", name.Name)
+			g.fields(e)
+			g.printf("}\n")
+		}
+	}
+}
+
+func (g *generator) fields(e *ast.RecordType) {
+	for _, d := range e.Fields {
+		field, ok := d.(*ast.Field)
+		if !ok {
+			continue
+		}
+		for _, name := range field.Names {
+			g.doc(field)
+			g.printf("%s %s\n", export(name.Name), g.typeExpr(field.Type))
+		}
+	}
+}
+
+func (g *generator) varDecl(d *ast.Field) {
+	for _, name := range d.Names {
+		g.printf("\n")
+		g.doc(d)
+		g.printf("var %s %s\n", name.Name, g.typeExpr(d.Type))
+	}
+}
+
+var builtins = map[string]string{
+	"Bool":   "bool",
+	"Float":  "float64",
+	"HTML":   "string",
+	"Int":    "int",
+	"String": "string",
+}
+
+// typeExpr returns the Go type denoted by a resolved type expression.
+func (g *generator) typeExpr(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return g.typeName(e)
+	case *ast.ListType:
+		return "[]" + g.typeExpr(e.Elem)
+	}
+	g.errorf(e.Pos().Start, "unexpected type expression")
+	return ""
+}
+
+// typeName returns the Go type denoted by a resolved ident.
+func (g *generator) typeName(ident *ast.Ident) string {
+	obj := ident.Obj
+	if obj == nil {
+		g.errorf(ident.Start, "unresolved type %s", ident.Name)
+		return ident.Name
+	}
+	if obj.Kind == ast.Builtin {
+		return builtins[obj.Name]
+	}
+	if qual, ok := g.quals[obj]; ok {
+		g.used[qual+" "+strconv.Quote(g.imports[qual])] = true
+		return qual + "." + obj.Name
+	}
+	return obj.Name
+}
+
+// export returns name with its first letter in upper case.
+func export(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+func (g *generator) templDecl(d *ast.TemplDecl) {
+	lit := d.Value.(*ast.TemplLit)
+	param := lit.Params[0]
+	pname := param.Names[0].Name
+	ptype := g.typeExpr(param.Type)
+
+	// the writer must not shadow the parameter or the names of the for
+	// elements
+	names := map[string]bool{pname: true}
+	ast.Inspect(lit, func(n ast.Node) bool {
+		if f, ok := n.(*ast.ForElement); ok {
+			for _, name := range []*ast.Ident{f.Index, f.Value} {
+				if name != nil {
+					names[name.Name] = true
+				}
+			}
+		}
+		return true
+	})
+	w := "w"
+	if names[w] {
+		w = "wr"
+	}
+	for names[w] {
+		w += "_"
+	}
+
+	g.use("io")
+	for _, name := range d.Names {
+		fn := "Render" + export(name.Name)
+		g.printf("\n")
+		g.printf("// %s writes the HTML of the templ %s to %s.\n", fn, name.Name, w)
+		g.printf("func %s(%s io.Writer, %s %s) error {\n", fn, w, pname, ptype)
+		m := markup{g: g, w: w}
+		for _, child := range lit.Body {
+			m.markup(child, escape.Text)
+		}
+		m.flush()
+		g.printf("return nil\n}\n")
+	}
+}
+
+// markup generates the statements of a templ body. The static parts are
+// joined and written with a single call.
+type markup struct {
+	g      *generator
+	w      string
+	static strings.Builder
+}
+
+func (m *markup) text(s string) {
+	m.static.WriteString(s)
+}
+
+func (m *markup) write(expr string) {
+	m.g.printf("if _, err := io.WriteString(%s, %s); err != nil {\nreturn err\n}\n", m.w, expr)
+}
+
+func (m *markup) flush() {
+	if m.static.Len() == 0 {
+		return
+	}
+	m.write(strconv.Quote(m.static.String()))
+	m.static.Reset()
+}
+
+// markup generates n, ctx is the context of the content of the enclosing
+// element.
+func (m *markup) markup(n ast.Markup, ctx escape.Context) {
+	switch n := n.(type) {
+	case *ast.Element:
+		m.element(n, ctx)
+	case *ast.Text:
+		m.text(escape.EscapeText(ctx, n.Value))
+	case *ast.Interp:
+		m.interp(n.X, ctx)
+	case *ast.IfElement:
+		m.ifElement(n, ctx)
+	case *ast.ForElement:
+		m.forElement(n, ctx)
+	}
+}
+
+// block generates the children of a Go block, the static parts are not
+// joined across the block.
+func (m *markup) block(children []ast.Markup, ctx escape.Context) {
+	for _, child := range children {
+		m.markup(child, ctx)
+	}
+	m.flush()
+}
+
+func (m *markup) ifElement(e *ast.IfElement, ctx escape.Context) {
+	m.flush()
+	m.g.printf("if %s {\n", m.expr(e.Cond))
+	m.block(e.Children, ctx)
+	if e.Else != nil {
+		m.g.printf("} else {\n")
+		m.block(e.Else.Children, ctx)
+	}
+	m.g.printf("}\n")
+}
+
+func (m *markup) forElement(e *ast.ForElement, ctx escape.Context) {
+	m.flush()
+	// Go rejects the unused names
+	index, value := "_", "_"
+	if e.Index != nil && uses(e.Children, e.Index.Obj) {
+		index = e.Index.Name
+	}
+	if uses(e.Children, e.Value.Obj) {
+		value = e.Value.Name
+	}
+	switch {
+	case index == "_" && value == "_":
+		m.g.printf("for range %s {\n", m.expr(e.X))
+	case value == "_":
+		m.g.printf("for %s := range %s {\n", index, m.expr(e.X))
+	default:
+		m.g.printf("for %s, %s := range %s {\n", index, value, m.expr(e.X))
+	}
+	m.block(e.Children, ctx)
+	m.g.printf("}\n")
+}
+
+// uses reports whether an ident of the children denotes obj.
+func uses(children []ast.Markup, obj *ast.Object) bool {
+	found := false
+	for _, child := range children {
+		ast.Inspect(child, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && id.Obj == obj {
+				found = true
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+func (m *markup) element(e *ast.Element, ctx escape.Context) {
+	name := e.Name.Name
+	m.text("<" + name)
+	for _, attr := range e.Attrs {
+		for _, key := range attr.Names {
+			m.text(" " + key.Name)
+			switch v := attr.Value.(type) {
+			case nil:
+			case *ast.BasicLit:
+				m.text(`="` + html.EscapeString(m.g.path(v)) + `"`)
+			default:
+				m.text(`="`)
+				m.interp(v, escape.In(ctx, name, key.Name))
+				m.text(`"`)
+			}
+		}
+	}
+	m.text(">")
+
+	// the checker rejected the children of the void elements
+	if escape.Void(name) {
+		return
+	}
+	for _, child := range e.Children {
+		m.markup(child, escape.In(ctx, name, ""))
+	}
+	m.text("</" + name + ">")
+}
+
+// interp generates the escaping of e for ctx. The checker rejected the
+// strings in the unsafe contexts and the HTML is trusted.
+func (m *markup) interp(e ast.Expr, ctx escape.Context) {
+	t := m.g.info.TypeOf(e)
+	basic, ok := t.Underlying().(*types.Basic)
+	if !ok || basic.Kind() == types.Invalid {
+		m.g.errorf(e.Pos().Start, "cannot generate an interpolation of type %s", t)
+		return
+	}
+
+	x := m.expr(e)
+	// values of a defined type are converted to their builtin type
+	if _, ok := t.(*types.Named); ok {
+		x = fmt.Sprintf("%s(%s)", builtins[basic.Name()], x)
+	}
+
+	m.flush()
+	switch basic.Kind() {
+	case types.String:
+		if ctx == escape.URL {
+			m.g.use("html")
+			m.g.use("strings")
+			m.g.filterURL = true
+			m.write(fmt.Sprintf("temFilterURL(%s)", x))
+			break
+		}
+		m.g.use("html")
+		m.write(fmt.Sprintf("html.EscapeString(%s)", x))
+	case types.HTML:
+		m.write(x)
+	case types.Int:
+		m.g.use("strconv")
+		m.write(fmt.Sprintf("strconv.Itoa(%s)", x))
+	case types.Float:
+		m.g.use("strconv")
+		m.write(fmt.Sprintf("strconv.FormatFloat(%s, 'g', -1, 64)", x))
+	case types.Bool:
+		m.g.use("strconv")
+		m.write(fmt.Sprintf("strconv.FormatBool(%s)", x))
+	}
+}
+
+func (m *markup) expr(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return m.expr(e.X) + "." + export(e.Sel.Name)
+	case *ast.BasicLit:
+		s, err := token.Unquote(e.Value)
+		if err != nil {
+			m.g.errorf(e.Start, "invalid string %s", e.Value)
+		}
+		return strconv.Quote(s)
+	case *ast.ParenExpr:
+		return "(" + m.expr(e.X) + ")"
+	case *ast.CallExpr:
+		return m.call(e)
+	default:
+		m.g.errorf(e.Pos().Start, "unexpected expression in interpolation")
+		return ""
+	}
+}
+
+// helpers are the Go functions implementing the helpers of the universe.
+var helpers = map[string]string{
+	"lower": "strings.ToLower",
+	"trim":  "strings.TrimSpace",
+	"upper": "strings.ToUpper",
+}
+
+// call generates a call to a helper, the arguments of a defined type are
+// converted to their builtin type.
+func (m *markup) call(e *ast.CallExpr) string {
+	fun, _ := e.Fun.(*ast.Ident)
+	if fun == nil || helpers[fun.Name] == "" {
+		m.g.errorf(e.Start, "unexpected call in interpolation")
+		return ""
+	}
+	m.g.use("strings")
+	args := make([]string, 0, len(e.Args))
+	for _, arg := range e.Args {
+		x := m.expr(arg)
+		if _, ok := m.g.info.TypeOf(arg).(*types.Named); ok {
+			x = "string(" + x + ")"
+		}
+		args = append(args, x)
+	}
+	return helpers[fun.Name] + "(" + strings.Join(args, ", ") + ")"
+}
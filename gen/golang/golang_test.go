@@ -0,0 +1,92 @@
+package golang_test
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/build"
+	goparser "go/parser"
+	gotoken "go/token"
+	"os"
+	"strconv"
+	"temlang/tem/gen/golang"
+	"temlang/tem/parser"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestGenerateGolden(t *testing.T) {
+	src, err := os.ReadFile("testdata/user.tem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, errs := parser.ParseFile("user.tem", src)
+	if errs.Len() != 0 {
+		t.Fatal("ParseFile failed unexpectedly")
+	}
+
+	var buf bytes.Buffer
+	if err := golang.Generate(&buf, file, errs); err != nil {
+		for !errs.Empty() {
+			err, _ := errs.Pop()
+			t.Error(err)
+		}
+		t.Fatal(err)
+	}
+
+	f, err := goparser.ParseFile(gotoken.NewFileSet(), "user.go", buf.Bytes(), 0)
+	if err != nil {
+		t.Fatalf("generated source does not parse: %v", err)
+	}
+	// the generated code only depends on the standard library
+	for _, spec := range f.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		if pkg, err := build.Import(path, "", build.FindOnly); err != nil || !pkg.Goroot {
+			t.Errorf("generated source imports %s, not a standard library package", path)
+		}
+	}
+
+	golden := "testdata/user.go.golden"
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(want), buf.String()); diff != "" {
+		t.Errorf("Generate mismatch (-want +got):\n%s", diff)
+	}
+}
+
+var invalids = []string{
+	`t :: record{}`,
+	`p :: package("a-b")`,
+	`p :: package("m");   t :: record{}; t :: templ(v: t){ <br <p/>/> }`,
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <p (v.b)/> }`,
+}
+
+func TestGenerateInvalids(t *testing.T) {
+	for i, src := range invalids {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			file, errs := parser.ParseFile("test.tem", []byte(src))
+			if errs.Len() != 0 {
+				t.Fatalf("ParseFile(%s) failed unexpectedly", src)
+			}
+			var buf bytes.Buffer
+			err := golang.Generate(&buf, file, errs)
+			if !errors.Is(err, golang.ErrInvalid) || errs.Len() == 0 {
+				t.Errorf("Generate(%s) succeeded unexpectedly", src)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("Generate(%s) wrote %q", src, buf.String())
+			}
+		})
+	}
+}
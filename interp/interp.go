@@ -0,0 +1,410 @@
+// Package interp renders the templs to HTML at runtime, without
+// generating and compiling Go.
+//
+// The parameter of a templ is bound to a Go value: a struct whose fields
+// are named after the fields of the record with the first letter in upper
+// case, like the generated Go, or tagged with the json name of the field,
+// or a map with string keys like the objects decoded from JSON.
+//
+//	in, err := interp.New(ns)
+//	in.Render(w, templ, User{Name: "Ada"})
+//	in.Render(w, templ, map[string]any{"name": "Ada"})
+//
+// A list is bound to a slice or an array, a Bool to a bool.
+//
+// The output is the output of the Go generated from the templ: the
+// interpolations are escaped for their HTML context and the values of
+// type HTML are trusted.
+package interp
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"temlang/tem/ast"
+	"temlang/tem/escape"
+	"temlang/tem/token"
+	"temlang/tem/types"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Error is a runtime error of a templ located in its source.
+type Error struct {
+	token.Location
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Start.Line, e.Start.Col, e.Msg)
+}
+
+// Interp renders the templs of a type checked namespace.
+type Interp struct {
+	ns   *ast.Namespace
+	info *types.Info
+}
+
+// New type checks ns once for all the renderings and returns the
+// interpreter of its templs, or the first error of the namespace. The
+// namespace must not be resolved yet: the checker does not resolve it
+// again and the errors of the resolution would be lost.
+func New(ns *ast.Namespace) (*Interp, error) {
+	errs := &token.ErrorQueue{}
+	info := types.Check(ns, errs)
+	if err, ok := errs.Pop(); ok {
+		return nil, &Error{Location: ns.Location(ast.Position{Start: err.Offset(), End: err.End()}), Msg: err.Message()}
+	}
+	return &Interp{ns: ns, info: info}, nil
+}
+
+// Render writes the HTML of the templ to w with data bound to its
+// parameter. Nothing is written if the rendering fails.
+func (in *Interp) Render(w io.Writer, templ *ast.TemplDecl, data any) error {
+	ns, info := in.ns, in.info
+	lit, ok := templ.Value.(*ast.TemplLit)
+	if !ok {
+		return errorAt(ns, templ.Position, "not a templ literal")
+	}
+	if len(lit.Params) != 1 || len(lit.Params[0].Names) != 1 {
+		return errorAt(ns, lit.Position, "a templ must have a single parameter")
+	}
+
+	param := lit.Params[0].Names[0]
+	r := renderer{ns: ns, info: info, vars: map[*ast.Object]reflect.Value{param.Obj: reflect.ValueOf(data)}}
+	for _, m := range lit.Body {
+		if err := r.markup(m, escape.Text); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(r.buf.Bytes())
+	return err
+}
+
+func errorAt(ns *ast.Namespace, pos ast.Position, msg string) *Error {
+	return &Error{Location: ns.Location(pos), Msg: msg}
+}
+
+type renderer struct {
+	ns   *ast.Namespace
+	info *types.Info
+	// vars are the values of the param and of the names of the for
+	// elements being rendered
+	vars map[*ast.Object]reflect.Value
+	buf  bytes.Buffer
+}
+
+func (r *renderer) errorf(pos ast.Position, format string, args ...any) *Error {
+	return errorAt(r.ns, pos, fmt.Sprintf(format, args...))
+}
+
+// markup renders n, ctx is the context of the content of the enclosing
+// element.
+func (r *renderer) markup(n ast.Markup, ctx escape.Context) error {
+	switch n := n.(type) {
+	case *ast.Element:
+		return r.element(n, ctx)
+	case *ast.Text:
+		r.buf.WriteString(escape.EscapeText(ctx, n.Value))
+	case *ast.Interp:
+		return r.interp(n.X, ctx)
+	case *ast.IfElement:
+		return r.ifElement(n, ctx)
+	case *ast.ForElement:
+		return r.forElement(n, ctx)
+	default:
+		return r.errorf(n.Pos(), "invalid markup")
+	}
+	return nil
+}
+
+func (r *renderer) element(e *ast.Element, ctx escape.Context) error {
+	name := e.Name.Name
+	r.buf.WriteString("<" + name)
+	for _, attr := range e.Attrs {
+		for _, key := range attr.Names {
+			r.buf.WriteString(" " + key.Name)
+			switch v := attr.Value.(type) {
+			case nil:
+			case *ast.BasicLit:
+				s, err := token.Unquote(v.Value)
+				if err != nil {
+					s = v.Value
+				}
+				r.buf.WriteString(`="` + html.EscapeString(s) + `"`)
+			default:
+				r.buf.WriteString(`="`)
+				if err := r.interp(v, escape.In(ctx, name, key.Name)); err != nil {
+					return err
+				}
+				r.buf.WriteString(`"`)
+			}
+		}
+	}
+	r.buf.WriteString(">")
+
+	// the checker rejected the children of the void elements
+	if escape.Void(name) {
+		return nil
+	}
+	if err := r.children(e.Children, escape.In(ctx, name, "")); err != nil {
+		return err
+	}
+	r.buf.WriteString("</" + name + ">")
+	return nil
+}
+
+func (r *renderer) children(children []ast.Markup, ctx escape.Context) error {
+	for _, child := range children {
+		if err := r.markup(child, ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *renderer) ifElement(e *ast.IfElement, ctx escape.Context) error {
+	v, err := r.eval(e.Cond)
+	if err != nil {
+		return err
+	}
+	if v.Kind() != reflect.Bool {
+		return r.errorf(e.Cond.Pos(), "cannot use %s of kind %s as a condition", exprString(e.Cond), v.Kind())
+	}
+	switch {
+	case v.Bool():
+		return r.children(e.Children, ctx)
+	case e.Else != nil:
+		return r.children(e.Else.Children, ctx)
+	}
+	return nil
+}
+
+func (r *renderer) forElement(e *ast.ForElement, ctx escape.Context) error {
+	v, err := r.eval(e.X)
+	if err != nil {
+		return err
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return r.errorf(e.X.Pos(), "cannot range over %s of kind %s", exprString(e.X), v.Kind())
+	}
+	for i := range v.Len() {
+		r.vars[e.Value.Obj] = v.Index(i)
+		if e.Index != nil {
+			r.vars[e.Index.Obj] = reflect.ValueOf(i)
+		}
+		if err := r.children(e.Children, ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// interp writes the value of e escaped for ctx, or as is if e is HTML.
+func (r *renderer) interp(e ast.Expr, ctx escape.Context) error {
+	v, err := r.eval(e)
+	if err != nil {
+		return err
+	}
+	t := r.info.TypeOf(e)
+	s, ok := format(v, t)
+	if !ok {
+		return r.errorf(e.Pos(), "cannot render %s of kind %s as %s", exprString(e), v.Kind(), t)
+	}
+	if isHTML(t) {
+		r.buf.WriteString(s)
+		return nil
+	}
+	r.buf.WriteString(escape.Escape(ctx, s))
+	return nil
+}
+
+func isHTML(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Kind() == types.HTML
+}
+
+// eval returns the value of e with the pointers and the interfaces
+// followed.
+func (r *renderer) eval(e ast.Expr) (reflect.Value, error) {
+	switch e := e.(type) {
+	case *ast.Ident:
+		v, ok := r.vars[e.Obj]
+		if !ok {
+			return reflect.Value{}, r.errorf(e.Position, "undefined: %s", e.Name)
+		}
+		v, ok = indirect(v)
+		if !ok {
+			return reflect.Value{}, r.errorf(e.Position, "%s is nil", e.Name)
+		}
+		return v, nil
+	case *ast.SelectorExpr:
+		x, err := r.eval(e.X)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v, ok := field(x, e.Sel.Name)
+		if !ok {
+			return reflect.Value{}, r.errorf(e.Sel.Position, "%s has no field %s", exprString(e.X), e.Sel.Name)
+		}
+		if v, ok = indirect(v); !ok {
+			return reflect.Value{}, r.errorf(e.Position, "%s is nil", exprString(e))
+		}
+		return v, nil
+	case *ast.BasicLit:
+		s, err := token.Unquote(e.Value)
+		if err != nil {
+			return reflect.Value{}, r.errorf(e.Position, "invalid string %s", e.Value)
+		}
+		return reflect.ValueOf(s), nil
+	case *ast.ParenExpr:
+		return r.eval(e.X)
+	case *ast.CallExpr:
+		return r.call(e)
+	default:
+		return reflect.Value{}, r.errorf(e.Pos(), "unexpected expression in interpolation")
+	}
+}
+
+// helpers are the implementations of the helpers of the universe.
+var helpers = map[string]func(string) string{
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"upper": strings.ToUpper,
+}
+
+// call returns the result of a call to a helper. The checker verified the
+// arguments are strings.
+func (r *renderer) call(e *ast.CallExpr) (reflect.Value, error) {
+	fun, _ := e.Fun.(*ast.Ident)
+	if fun == nil || helpers[fun.Name] == nil || len(e.Args) != 1 {
+		return reflect.Value{}, r.errorf(e.Position, "cannot call %s", exprString(e.Fun))
+	}
+	arg, err := r.eval(e.Args[0])
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if arg.Kind() != reflect.String {
+		return reflect.Value{}, r.errorf(e.Args[0].Pos(), "cannot use %s of kind %s as a string", exprString(e.Args[0]), arg.Kind())
+	}
+	return reflect.ValueOf(helpers[fun.Name](arg.String())), nil
+}
+
+// indirect follows the pointers and the interfaces of v and reports
+// whether the value is not nil.
+func indirect(v reflect.Value) (reflect.Value, bool) {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	return v, v.IsValid()
+}
+
+// field returns the field of a struct or the value of a map with string
+// keys named name.
+func field(v reflect.Value, name string) (reflect.Value, bool) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		if f, ok := t.FieldByName(export(name)); ok && f.IsExported() {
+			return v.FieldByIndex(f.Index), true
+		}
+		for i := range t.NumField() {
+			f := t.Field(i)
+			tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if f.IsExported() && tag == name {
+				return v.Field(i), true
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}, false
+		}
+		key := reflect.ValueOf(name).Convert(v.Type().Key())
+		if e := v.MapIndex(key); e.IsValid() {
+			return e, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// format returns the text of v as a value of the basic type t and reports
+// whether v is one. The numbers decoded from JSON are floats, a float is
+// an Int if it is a whole number.
+func format(v reflect.Value, t types.Type) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return "", false
+	}
+	switch b.Kind() {
+	case types.String, types.HTML:
+		if v.Kind() == reflect.String {
+			return v.String(), true
+		}
+	case types.Bool:
+		if v.Kind() == reflect.Bool {
+			return strconv.FormatBool(v.Bool()), true
+		}
+	case types.Int:
+		switch {
+		case v.CanInt():
+			return strconv.FormatInt(v.Int(), 10), true
+		case v.CanUint():
+			return strconv.FormatUint(v.Uint(), 10), true
+		case v.CanFloat():
+			f := v.Float()
+			if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+				return strconv.FormatInt(int64(f), 10), true
+			}
+		}
+	case types.Float:
+		switch {
+		case v.CanFloat():
+			return strconv.FormatFloat(v.Float(), 'g', -1, 64), true
+		case v.CanInt():
+			return strconv.FormatInt(v.Int(), 10), true
+		case v.CanUint():
+			return strconv.FormatUint(v.Uint(), 10), true
+		}
+	}
+	return "", false
+}
+
+// export returns name with its first letter in upper case.
+func export(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+func exprString(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.BasicLit:
+		return e.Value
+	case *ast.SelectorExpr:
+		return exprString(e.X) + "." + e.Sel.Name
+	case *ast.ParenExpr:
+		return "(" + exprString(e.X) + ")"
+	case *ast.CallExpr:
+		args := make([]string, 0, len(e.Args))
+		for _, arg := range e.Args {
+			args = append(args, exprString(arg))
+		}
+		return exprString(e.Fun) + "(" + strings.Join(args, ", ") + ")"
+	}
+	return "expression"
+}
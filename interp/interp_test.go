@@ -0,0 +1,337 @@
+package interp_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"temlang/tem/ast"
+	"temlang/tem/interp"
+	"temlang/tem/parser"
+	"testing"
+)
+
+const src = `p :: package("views")
+User :: record{ name: String; email: String; age: Int }
+User :: templ(u: User){
+	<div class="user & co" id=(u.name)
+		<p Username: (u.name)/>
+		<p Email: (u.email) (u.age)/>
+		<br/>
+		/>
+	}
+`
+
+const expected = `<div class="user &amp; co" id="&lt;Ada&gt;"><p>Username: &lt;Ada&gt;</p><p>Email: ada@example.com 36</p><br></div>`
+
+// parse returns the namespace of src and its templ.
+func parse(t *testing.T, src string) (*ast.Namespace, *ast.TemplDecl) {
+	t.Helper()
+	ns, errs := parser.ParseFile("user.tem", []byte(src))
+	if !errs.Empty() {
+		err, _ := errs.Pop()
+		t.Fatalf("parse failed unexpectedly: %s", err)
+	}
+	for _, d := range ns.Decls() {
+		if d, ok := d.(*ast.TemplDecl); ok {
+			return ns, d
+		}
+	}
+	t.Fatal("no templ")
+	return nil, nil
+}
+
+func render(t *testing.T, src string, data any) (string, error) {
+	t.Helper()
+	ns, templ := parse(t, src)
+	in, err := interp.New(ns)
+	if err != nil {
+		t.Fatalf("check failed unexpectedly: %s", err)
+	}
+	var b strings.Builder
+	err = in.Render(&b, templ, data)
+	return b.String(), err
+}
+
+type User struct {
+	Name    string
+	Address string `json:"email"`
+	Age     int
+}
+
+func TestRenderStruct(t *testing.T) {
+	for _, data := range []any{
+		User{Name: "<Ada>", Address: "ada@example.com", Age: 36},
+		&User{Name: "<Ada>", Address: "ada@example.com", Age: 36},
+	} {
+		got, err := render(t, src, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != expected {
+			t.Errorf("expected\n%s\ngot\n%s", expected, got)
+		}
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	var data map[string]any
+	if err := json.Unmarshal([]byte(`{"name": "<Ada>", "email": "ada@example.com", "age": 36}`), &data); err != nil {
+		t.Fatal(err)
+	}
+	got, err := render(t, src, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+// the numbers decoded from JSON are floats, an Int is rendered as an
+// integer
+func TestRenderJSONInt(t *testing.T) {
+	var data map[string]any
+	if err := json.Unmarshal([]byte(`{"name": "Ada", "email": "ada@example.com", "age": 1000000}`), &data); err != nil {
+		t.Fatal(err)
+	}
+	got, err := render(t, src, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<p>Email: ada@example.com 1000000</p>"; !strings.Contains(got, want) {
+		t.Errorf("expected %s in\n%s", want, got)
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	testcases := []struct {
+		name string
+		data any
+		err  string
+	}{
+		{
+			name: "missing key",
+			data: map[string]any{"name": "Ada", "age": 36},
+			err:  "user.tem:6:16: u has no field email",
+		},
+		{
+			name: "missing field",
+			data: struct{ Email string }{"ada@example.com"},
+			err:  "user.tem:4:31: u has no field name",
+		},
+		{
+			name: "nil",
+			data: (*User)(nil),
+			err:  "user.tem:4:29: u is nil",
+		},
+		{
+			name: "not basic",
+			data: map[string]any{"name": []string{"Ada"}},
+			err:  "user.tem:4:29: cannot render u.name of kind slice as String",
+		},
+		{
+			name: "mismatched kind",
+			data: map[string]any{"name": 5.0, "email": "ada@example.com", "age": "x"},
+			err:  "user.tem:4:29: cannot render u.name of kind float64 as String",
+		},
+		{
+			name: "fractional Int",
+			data: map[string]any{"name": "Ada", "email": "ada@example.com", "age": 36.5},
+			err:  "user.tem:6:24: cannot render u.age of kind float64 as Int",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := render(t, src, tc.data)
+			var ierr *interp.Error
+			if !errors.As(err, &ierr) {
+				t.Fatalf("expected an interp error got %v", err)
+			}
+			if err.Error() != tc.err {
+				t.Errorf("expected %q got %q", tc.err, err)
+			}
+			if got != "" {
+				t.Errorf("expected no output got %s", got)
+			}
+		})
+	}
+}
+
+func TestRenderVoidChildren(t *testing.T) {
+	src := `p :: package("views")
+User :: record{ name: String }
+User :: templ(u: User){ <br (u.name)/> }
+`
+	ns, _ := parse(t, src)
+	_, err := interp.New(ns)
+	if err == nil || err.Error() != "user.tem:3:25: void element br cannot have children" {
+		t.Errorf("expected a void element error got %v", err)
+	}
+}
+
+func TestRenderEscape(t *testing.T) {
+	src := `p :: package("views")
+User :: record{ site: String; bio: HTML; code: HTML }
+User :: templ(u: User){
+	<a href=(u.site) title=(u.site) (u.bio)/>
+	<script (u.code)/>
+	}
+`
+	data := map[string]any{"site": "javascript:alert(1)", "bio": "<b>Ada</b>", "code": "f()"}
+	got, err := render(t, src, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<a href="#ZtemZ" title="javascript:alert(1)"><b>Ada</b></a><script>f()</script>`
+	if got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+// the literal text of a script is raw text, it is not escaped
+func TestRenderScriptText(t *testing.T) {
+	src := `p :: package("views")
+User :: record{ name: String }
+User :: templ(u: User){ <p 1 < 2/><script var x = 1 < 2 && "a";/> }
+`
+	got, err := render(t, src, User{Name: "Ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<p>1 &lt; 2</p><script>var x = 1 < 2 && "a";</script>`
+	if got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestRenderUnchecked(t *testing.T) {
+	src := `p :: package("views")
+User :: record{ code: String }
+User :: templ(u: User){ <script (u.code)/> }
+`
+	ns, _ := parse(t, src)
+	_, err := interp.New(ns)
+	expected := "user.tem:3:34: cannot interpolate a String in a script, only HTML is trusted there"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q got %v", expected, err)
+	}
+}
+
+// the namespace is checked once, the errors are not lost on the second
+// rendering
+func TestRenderTwice(t *testing.T) {
+	ns, templ := parse(t, src)
+	in, err := interp.New(ns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		var b strings.Builder
+		if err := in.Render(&b, templ, User{Name: "<Ada>", Address: "ada@example.com", Age: 36}); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != expected {
+			t.Errorf("expected\n%s\ngot\n%s", expected, b.String())
+		}
+	}
+
+	ns, _ = parse(t, `p :: package("views")
+User :: record{ tags: []String }
+User :: templ(u: User){ <for (i, i in u.tags) (i)/> }
+`)
+	if _, err := interp.New(ns); err == nil || !strings.HasSuffix(err.Error(), "i redeclared") {
+		t.Errorf("expected a redeclaration error got %v", err)
+	}
+}
+
+func TestRenderHelpers(t *testing.T) {
+	src := `p :: package("views")
+User :: record{ name: String; email: String }
+User :: templ(u: User){ <p title=(lower(u.email)) (upper((u.name))) ("&") (trim(" x "))/> }
+`
+	got, err := render(t, src, User{Name: "<Ada>", Address: "Ada@Example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<p title="ada@example.com">&lt;ADA&gt; &amp; x</p>`
+	if got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+const controlSrc = `p :: package("views")
+Team :: record{ name: String; public: Bool; members: []String }
+Team :: templ(t: Team){
+	<if (t.public) <h1 (t.name)/>/>
+	<else <h1 private/>/>
+	<ul <for (i, m in t.members) <li (i): (m)/>/>/>
+	}
+`
+
+type Team struct {
+	Name    string
+	Public  bool
+	Members []string
+}
+
+func TestRenderControlFlow(t *testing.T) {
+	testcases := []struct {
+		name     string
+		data     any
+		expected string
+	}{
+		{
+			name:     "struct",
+			data:     Team{Name: "<core>", Public: true, Members: []string{"Ada", "Bob"}},
+			expected: `<h1>&lt;core&gt;</h1><ul><li>0: Ada</li><li>1: Bob</li></ul>`,
+		},
+		{
+			name:     "else",
+			data:     &Team{Name: "core"},
+			expected: `<h1>private</h1><ul></ul>`,
+		},
+		{
+			name:     "json",
+			data:     map[string]any{"name": "core", "public": false, "members": []any{"Ada"}},
+			expected: `<h1>private</h1><ul><li>0: Ada</li></ul>`,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := render(t, controlSrc, tc.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.expected {
+				t.Errorf("expected\n%s\ngot\n%s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRenderControlFlowErrors(t *testing.T) {
+	testcases := []struct {
+		name string
+		data any
+		err  string
+	}{
+		{
+			name: "condition",
+			data: map[string]any{"public": "yes"},
+			err:  "user.tem:4:7: cannot use t.public of kind string as a condition",
+		},
+		{
+			name: "range",
+			data: map[string]any{"public": true, "name": "core", "members": "Ada"},
+			err:  "user.tem:6:20: cannot range over t.members of kind string",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := render(t, controlSrc, tc.data)
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("expected %q got %v", tc.err, err)
+			}
+		})
+	}
+}
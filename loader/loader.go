@@ -0,0 +1,255 @@
+// Package loader loads the packages of a directory tree.
+//
+// Every .tem file of the tree is parsed and its namespace added to the
+// package named by its package declaration, whatever the directory of the
+// file. An import("path") declaration imports the loaded package named
+// path. The names declared by using denote the types of the same names
+// in the other namespaces of a package:
+//
+//	home/def.tem      p :: package("home"); b :: import("books");
+//	                  Book :: using(b); Button :: using(p.widgets);
+//	home/widgets.tem  p :: package("home"); Button :: record{ label: String };
+//	books/list.tem    p :: package("books"); Book :: record{ title: String };
+//
+// The directories starting with a dot and the testdata directories are
+// skipped.
+package loader
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"temlang/tem/ast"
+	"temlang/tem/parser"
+	"temlang/tem/token"
+)
+
+// Program is the set of packages loaded from a directory tree.
+type Program struct {
+	// Files are the files of the tree sorted by name.
+	Files []*File
+	// Packages maps the names of the packages to the packages.
+	Packages map[string]*Package
+}
+
+// File is a parsed file of a program.
+type File struct {
+	Namespace *ast.Namespace
+	Src       []byte
+	// Errors are the errors of the parsing followed by the errors of the
+	// loading.
+	Errors *token.ErrorQueue
+}
+
+// Package is the set of the namespaces declaring the same package.
+type Package struct {
+	Name string
+	// Files are the files of the package sorted by name.
+	Files []*File
+	// Imports maps the import paths of the package to the packages.
+	Imports map[string]*Package
+}
+
+// Namespaces returns the namespaces of the files of the package.
+func (p *Package) Namespaces() []*ast.Namespace {
+	ns := make([]*ast.Namespace, 0, len(p.Files))
+	for _, f := range p.Files {
+		ns = append(ns, f.Namespace)
+	}
+	return ns
+}
+
+// Load parses the .tem files of the tree rooted at root, links the
+// packages they declare and resolves the names of the namespaces. The
+// error reports a file that can't be read, the
+// errors of the files are in their error queues.
+func Load(root string, opts ...parser.Option) (*Program, error) {
+	prog := &Program{Packages: map[string]*Package{}}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".tem") {
+			return nil
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		ns, errs := parser.ParseFile(path, src, opts...)
+		prog.Files = append(prog.Files, &File{Namespace: ns, Src: src, Errors: errs})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l := loader{
+		prog:     prog,
+		imports:  map[*Package][]importRef{},
+		packages: map[*File]*Package{},
+		imported: map[*ast.ImportDecl]*Package{},
+	}
+	l.group()
+	l.link()
+	l.cycles()
+	l.resolve()
+	return prog, nil
+}
+
+// importRef is an import declaration of a package.
+type importRef struct {
+	file *File
+	lit  *ast.BasicLit
+	path string
+}
+
+type loader struct {
+	prog *Program
+	// imports are the import declarations of each package in file order
+	imports map[*Package][]importRef
+	// packages maps the files to the packages they declare
+	packages map[*File]*Package
+	// imported maps the import declarations to the packages they import
+	imported map[*ast.ImportDecl]*Package
+}
+
+func errorAt(f *File, pos ast.Position, msg string) {
+	err := token.NewError(pos.Start, msg).WithEnd(pos.End).WithCode(token.CodeLoad)
+	f.Errors.Push(err)
+}
+
+// group adds the files to the packages they declare.
+func (l *loader) group() {
+	sort.Slice(l.prog.Files, func(i, j int) bool {
+		return l.prog.Files[i].Namespace.File() < l.prog.Files[j].Namespace.File()
+	})
+	for _, f := range l.prog.Files {
+		name := f.Namespace.PackageName()
+		if name == "" {
+			if !hasPackageDecl(f.Namespace) {
+				errorAt(f, ast.Position{}, "missing package declaration")
+			}
+			continue
+		}
+		pkg := l.prog.Packages[name]
+		if pkg == nil {
+			pkg = &Package{Name: name, Imports: map[string]*Package{}}
+			l.prog.Packages[name] = pkg
+		}
+		pkg.Files = append(pkg.Files, f)
+		l.packages[f] = pkg
+	}
+}
+
+// hasPackageDecl reports whether ns declares a package, an empty name
+// is reported by the generators.
+func hasPackageDecl(ns *ast.Namespace) bool {
+	for _, d := range ns.Decls() {
+		if _, ok := d.(*ast.PackageDecl); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// link resolves the import paths of the packages.
+func (l *loader) link() {
+	for _, pkg := range l.sorted() {
+		for _, f := range pkg.Files {
+			for _, d := range f.Namespace.Decls() {
+				imp, ok := d.(*ast.ImportDecl)
+				if !ok {
+					continue
+				}
+				e, ok := imp.Value.(*ast.ImportExpr)
+				if !ok || e.Path == nil {
+					continue
+				}
+				path, err := token.Unquote(e.Path.Value)
+				if err != nil {
+					// reported by the tokenizer
+					continue
+				}
+				target := l.prog.Packages[path]
+				if target == nil {
+					errorAt(f, e.Path.Position, fmt.Sprintf("package %q not found", path))
+					continue
+				}
+				pkg.Imports[path] = target
+				l.imported[imp] = target
+				l.imports[pkg] = append(l.imports[pkg], importRef{file: f, lit: e.Path, path: path})
+			}
+		}
+	}
+}
+
+// cycles reports the import cycles at the import closing them.
+func (l *loader) cycles() {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[*Package]int{}
+	var stack []*Package
+
+	var visit func(pkg *Package)
+	visit = func(pkg *Package) {
+		state[pkg] = visiting
+		stack = append(stack, pkg)
+		for _, ref := range l.imports[pkg] {
+			target := pkg.Imports[ref.path]
+			switch state[target] {
+			case unvisited:
+				visit(target)
+			case visiting:
+				errorAt(ref.file, ref.lit.Position, "import cycle: "+cyclePath(stack, target))
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[pkg] = visited
+	}
+
+	for _, pkg := range l.sorted() {
+		if state[pkg] == unvisited {
+			visit(pkg)
+		}
+	}
+}
+
+// cyclePath returns the path of the packages of the stack from target
+// back to target.
+func cyclePath(stack []*Package, target *Package) string {
+	i := len(stack) - 1
+	for stack[i] != target {
+		i--
+	}
+	var names []string
+	for _, pkg := range stack[i:] {
+		names = append(names, pkg.Name)
+	}
+	names = append(names, target.Name)
+	return strings.Join(names, " -> ")
+}
+
+// sorted returns the packages sorted by name.
+func (l *loader) sorted() []*Package {
+	pkgs := make([]*Package, 0, len(l.prog.Packages))
+	for _, pkg := range l.prog.Packages {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Name < pkgs[j].Name })
+	return pkgs
+}
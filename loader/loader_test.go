@@ -0,0 +1,254 @@
+package loader_test
+
+import (
+	"os"
+	"path/filepath"
+	"temlang/tem/ast"
+	"temlang/tem/loader"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// tree writes the files to a temporary directory and returns it.
+func tree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, src := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func load(t *testing.T, files map[string]string) (*loader.Program, string) {
+	t.Helper()
+	root := tree(t, files)
+	prog, err := loader.Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return prog, root
+}
+
+// errors returns the messages of the errors of the files by file name
+// relative to root.
+func errors(t *testing.T, prog *loader.Program, root string) map[string][]string {
+	t.Helper()
+	msgs := map[string][]string{}
+	for _, f := range prog.Files {
+		name, _ := filepath.Rel(root, f.Namespace.File())
+		for !f.Errors.Empty() {
+			err, _ := f.Errors.Pop()
+			msgs[filepath.ToSlash(name)] = append(msgs[filepath.ToSlash(name)], err.Message())
+		}
+	}
+	return msgs
+}
+
+func TestLoadGroupsByPackage(t *testing.T) {
+	prog, root := load(t, map[string]string{
+		"home/def.tem":      `p :: package("home"); b :: import("books");`,
+		"home/template.tem": `p :: package("home");`,
+		"books/list.tem":    `p :: package("books");`,
+		"other/extra.tem":   `p :: package("books");`,
+		"home/notes.txt":    `not a tem file`,
+		".git/x.tem":        `p :: package("hidden");`,
+		"testdata/x.tem":    `p :: package("testdata");`,
+	})
+	if diff := cmp.Diff(map[string][]string{}, errors(t, prog, root)); diff != "" {
+		t.Error(diff)
+	}
+
+	got := map[string][]string{}
+	for name, pkg := range prog.Packages {
+		for _, ns := range pkg.Namespaces() {
+			rel, _ := filepath.Rel(root, ns.File())
+			got[name] = append(got[name], filepath.ToSlash(rel))
+		}
+	}
+	expected := map[string][]string{
+		"home":  {"home/def.tem", "home/template.tem"},
+		"books": {"books/list.tem", "other/extra.tem"},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Error(diff)
+	}
+
+	home := prog.Packages["home"]
+	if home.Imports["books"] != prog.Packages["books"] {
+		t.Errorf("import books of home is %v", home.Imports["books"])
+	}
+	if name := home.Files[1].Namespace.Name(); name != "template" {
+		t.Errorf("namespace name is %q, expected template", name)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected map[string][]string
+	}{
+		{
+			name:  "not found",
+			files: map[string]string{"a.tem": `p :: package("a"); b :: import("b");`},
+			expected: map[string][]string{
+				"a.tem": {`package "b" not found`},
+			},
+		},
+		{
+			name:  "missing package",
+			files: map[string]string{"a.tem": `t :: record{}`},
+			expected: map[string][]string{
+				"a.tem": {"missing package declaration"},
+			},
+		},
+		{
+			name:  "self import",
+			files: map[string]string{"a.tem": `p :: package("a"); a :: import("a");`},
+			expected: map[string][]string{
+				"a.tem": {"import cycle: a -> a"},
+			},
+		},
+		{
+			name: "cycle",
+			files: map[string]string{
+				"a/a.tem": `p :: package("a"); b :: import("b");`,
+				"b/b.tem": `p :: package("b"); c :: import("c");`,
+				"c/c.tem": `p :: package("c"); a :: import("a");`,
+				"d/d.tem": `p :: package("d"); a :: import("a");`,
+			},
+			expected: map[string][]string{
+				"c/c.tem": {"import cycle: a -> b -> c -> a"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prog, root := load(t, test.files)
+			if diff := cmp.Diff(test.expected, errors(t, prog, root)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestLoadCycleLocation(t *testing.T) {
+	src := `p :: package("a"); a :: import("a");`
+	prog, _ := load(t, map[string]string{"a.tem": src})
+	err, ok := prog.Files[0].Errors.Pop()
+	if !ok {
+		t.Fatal("expected an import cycle")
+	}
+	if got := src[err.Offset():err.End()]; got != `"a"` {
+		t.Errorf("error spans %s, expected the import path", got)
+	}
+}
+
+func TestLoadUsing(t *testing.T) {
+	prog, root := load(t, map[string]string{
+		"home/def.tem": `p :: package("home"); b :: import("books");
+			Book :: using(b); Button :: using(p.widgets); Link :: using(p);
+			Row :: record{ book: Book; button: Button; link: Link };`,
+		"home/widgets.tem": `p :: package("home"); Button :: record{ label: String };`,
+		"home/links.tem":   `p :: package("home"); Link :: record{ href: String };`,
+		"books/list.tem":   `p :: package("books"); Book :: record{ title: String };`,
+	})
+	if diff := cmp.Diff(map[string][]string{}, errors(t, prog, root)); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// the type declared by each name of a namespace
+	declared := func(ns *ast.Namespace) map[string]*ast.Object {
+		objs := map[string]*ast.Object{}
+		for _, d := range ns.Decls() {
+			if d, ok := d.(*ast.TypeDecl); ok {
+				for _, name := range d.Names {
+					objs[name.Name] = name.Obj
+				}
+			}
+		}
+		return objs
+	}
+	home := prog.Packages["home"]
+	books := prog.Packages["books"]
+	expected := map[string]*ast.Object{
+		"Book":   declared(books.Files[0].Namespace)["Book"],
+		"Button": declared(home.Files[2].Namespace)["Button"],
+		"Link":   declared(home.Files[1].Namespace)["Link"],
+	}
+	for _, d := range home.Files[0].Namespace.Decls() {
+		d, ok := d.(*ast.UsingDecl)
+		if !ok {
+			continue
+		}
+		name := d.Names[0]
+		if name.Obj.Target == nil || name.Obj.Target != expected[name.Name] {
+			t.Errorf("%s denotes %v, expected %v", name.Name, name.Obj.Target, expected[name.Name])
+		}
+	}
+}
+
+func TestLoadUsingErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected map[string][]string
+	}{
+		{
+			name: "namespace not found",
+			files: map[string]string{
+				"a.tem": `p :: package("a"); B :: using(p.b);`,
+			},
+			expected: map[string][]string{
+				"a.tem": {"namespace b not found in package a"},
+			},
+		},
+		{
+			name: "not declared",
+			files: map[string]string{
+				"a.tem": `p :: package("a"); B :: using(p.b);`,
+				"b.tem": `p :: package("a"); C :: record{};`,
+			},
+			expected: map[string][]string{
+				"a.tem": {"B not declared by p.b"},
+			},
+		},
+		{
+			name: "not reexported",
+			files: map[string]string{
+				"a.tem": `p :: package("a"); B :: using(p.b);`,
+				"b.tem": `p :: package("a"); i :: import("c"); B :: using(i);`,
+				"c.tem": `p :: package("c"); B :: record{};`,
+			},
+			expected: map[string][]string{
+				"a.tem": {"B not declared by p.b"},
+			},
+		},
+		{
+			name: "ambiguous",
+			files: map[string]string{
+				"a.tem": `p :: package("a"); B :: using(p);`,
+				"b.tem": `p :: package("a"); B :: record{};`,
+				"c.tem": `p :: package("a"); B :: record{};`,
+			},
+			expected: map[string][]string{
+				"a.tem": {"B is declared by namespaces b and c of package a"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prog, root := load(t, test.files)
+			if diff := cmp.Diff(test.expected, errors(t, prog, root)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package loader
+
+import (
+	"fmt"
+	"temlang/tem/ast"
+	"temlang/tem/resolver"
+	"temlang/tem/token"
+)
+
+// resolve resolves the names of each namespace then links the names
+// declared by using to the types they denote.
+func (l *loader) resolve() {
+	for _, f := range l.prog.Files {
+		resolver.Resolve(f.Namespace, f.Errors)
+	}
+	for _, f := range l.prog.Files {
+		for _, d := range f.Namespace.Decls() {
+			if d, ok := d.(*ast.UsingDecl); ok {
+				l.using(f, d)
+			}
+		}
+	}
+}
+
+// using links the names of a using declaration to the types of the same
+// names in the namespaces of its target:
+//
+//	using(p)     the other namespaces of the package p declares
+//	using(i)     the namespaces of the package i imports
+//	using(p.ns)  the namespace ns of the package p declares or imports
+//
+// Only the types declared by a type declaration are found, the names
+// declared by using are not used again.
+func (l *loader) using(f *File, d *ast.UsingDecl) {
+	e, ok := d.Value.(*ast.UsingExpr)
+	if !ok {
+		return
+	}
+	var pkgIdent, nsIdent *ast.Ident
+	switch t := e.Target.(type) {
+	case *ast.Ident:
+		pkgIdent = t
+	case *ast.SelectorExpr:
+		x, ok := t.X.(*ast.Ident)
+		if !ok {
+			errorAt(f, t.Position, "using target must be a package or a namespace of a package")
+			return
+		}
+		pkgIdent, nsIdent = x, t.Sel
+	default:
+		return
+	}
+
+	obj := pkgIdent.Obj
+	if obj == nil {
+		// reported by the resolver
+		return
+	}
+	var pkg *Package
+	switch obj.Kind {
+	case ast.Pkg:
+		pkg = l.packages[f]
+	case ast.Imp:
+		imp, _ := obj.Decl.(*ast.ImportDecl)
+		pkg = l.imported[imp]
+	}
+	if pkg == nil {
+		// not a package or not found, reported by the resolver or by link
+		return
+	}
+
+	var namespaces []*File
+	for _, other := range pkg.Files {
+		switch {
+		case nsIdent != nil:
+			if other.Namespace.Name() == nsIdent.Name {
+				namespaces = append(namespaces, other)
+			}
+		case other != f:
+			namespaces = append(namespaces, other)
+		}
+	}
+	if nsIdent != nil && len(namespaces) == 0 {
+		errorAt(f, nsIdent.Position, fmt.Sprintf("namespace %s not found in package %s", nsIdent.Name, pkg.Name))
+		return
+	}
+
+	for _, name := range d.Names {
+		if name.Obj == nil {
+			continue
+		}
+		var found *ast.Object
+		var from *File
+		for _, other := range namespaces {
+			target := other.Namespace.Scope().Lookup(name.Name)
+			if target == nil || target.Kind != ast.Typ {
+				continue
+			}
+			if _, ok := target.Decl.(*ast.TypeDecl); !ok {
+				continue
+			}
+			if found != nil {
+				err := token.NewError(name.Start, fmt.Sprintf("%s is declared by namespaces %s and %s of package %s",
+					name.Name, from.Namespace.Name(), other.Namespace.Name(), pkg.Name))
+				f.Errors.Push(err.WithEnd(name.End).WithCode(token.CodeLoad))
+				found = nil
+				break
+			}
+			found, from = target, other
+		}
+		if found == nil {
+			if from == nil {
+				errorAt(f, name.Position, fmt.Sprintf("%s not declared by %s", name.Name, usingTarget(e.Target)))
+			}
+			continue
+		}
+		name.Obj.Target = found
+	}
+}
+
+func usingTarget(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return usingTarget(e.X) + "." + e.Sel.Name
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"fmt"
+	"temlang/tem/ast"
+	"temlang/tem/token"
+)
+
+// annotate attaches the docs and tags of a list of declarations to the
+// declarations with the same names. A doc or a tag is attached to the
+// first declaration of a name after it, or to the last one before it if
+// it follows all of them. The docs and tags of the fields of a record are
+// attached within the record. The docs and tags without a declaration are
+// reported to errors with the code CodeAnnotate whatever the state of the
+// parser.
+func annotate(decls []ast.Decl, errors *token.ErrorQueue) {
+	for i, d := range decls {
+		switch d := d.(type) {
+		case *ast.Doc:
+			for _, name := range d.Names {
+				target := annotated(decls, i, name.Name)
+				if target == nil {
+					orphan(errors, name, "documentation")
+					continue
+				}
+				target.AddDoc(d)
+			}
+		case *ast.Tag:
+			for _, name := range d.Names {
+				target := annotated(decls, i, name.Name)
+				if target == nil {
+					orphan(errors, name, "tag")
+					continue
+				}
+				target.AddTag(d)
+			}
+		case *ast.TypeDecl:
+			if r, ok := d.Value.(*ast.RecordType); ok {
+				annotate(r.Fields, errors)
+			}
+		}
+	}
+}
+
+// orphan reports the doc or the tag of name without a declaration.
+func orphan(errors *token.ErrorQueue, name *ast.Ident, what string) {
+	msg := fmt.Sprintf("%s of %s has no declaration", what, name.Name)
+	err := token.NewError(name.Start, msg).WithEnd(name.End).WithCode(token.CodeAnnotate)
+	errors.Push(err)
+}
+
+// annotated returns the declaration of name closest to decls[i], the
+// declarations after i first.
+func annotated(decls []ast.Decl, i int, name string) ast.Annotated {
+	for _, d := range decls[i+1:] {
+		if a, ok := d.(ast.Annotated); ok && declares(a, name) {
+			return a
+		}
+	}
+	for j := i - 1; j >= 0; j-- {
+		if a, ok := decls[j].(ast.Annotated); ok && declares(a, name) {
+			return a
+		}
+	}
+	return nil
+}
+
+func declares(d ast.Decl, name string) bool {
+	var names []*ast.Ident
+	switch d := d.(type) {
+	case *ast.PackageDecl:
+		names = d.Names
+	case *ast.ImportDecl:
+		names = d.Names
+	case *ast.UsingDecl:
+		names = d.Names
+	case *ast.TypeDecl:
+		names = d.Names
+	case *ast.TemplDecl:
+		names = d.Names
+	case *ast.Field:
+		names = d.Names
+	}
+	for _, n := range names {
+		if n.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"temlang/tem/ast"
+	"temlang/tem/token"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAnnotate(t *testing.T) {
+	src := `
+		p :: package("a")
+
+		User : "a user"
+		User : { json = "user" }
+		User :: record{
+			name: String
+			name: "the name"
+			}
+		User : "rendered user"
+		User :: templ(u: User){}
+		User : { after = "templ" }
+		A, B : "two names"
+		A, B :: type(String)
+	`
+
+	file, errs := ParseFile("test.tem", []byte(src))
+	for !errs.Empty() {
+		err, _ := errs.Pop()
+		t.Fatal(err)
+	}
+
+	decls := file.Decls()
+	record := decls[3].(*ast.TypeDecl)
+	templ := decls[5].(*ast.TemplDecl)
+	ab := decls[8].(*ast.TypeDecl)
+	field := record.Value.(*ast.RecordType).Fields[0].(*ast.Field)
+
+	if docs := record.Doc(); len(docs) != 1 || docs[0] != decls[1] {
+		t.Errorf("expected the record to be documented by %v got %v", decls[1], docs)
+	}
+	if tags := record.Tags(); len(tags) != 1 || tags[0] != decls[2] {
+		t.Errorf("expected the record to be tagged by %v got %v", decls[2], tags)
+	}
+	if docs := field.Doc(); len(docs) != 1 || docs[0].Text[0].Value != `"the name"` {
+		t.Errorf("expected the field to be documented got %v", docs)
+	}
+	if docs := templ.Doc(); len(docs) != 1 || docs[0] != decls[4] {
+		t.Errorf("expected the templ to be documented by %v got %v", decls[4], docs)
+	}
+	if tags := templ.Tags(); len(tags) != 1 || tags[0] != decls[6] {
+		t.Errorf("expected the templ to be tagged by %v got %v", decls[6], tags)
+	}
+	if docs := ab.Doc(); len(docs) != 2 {
+		t.Errorf("expected a doc per name got %v", docs)
+	}
+}
+
+func TestAnnotateOrphans(t *testing.T) {
+	srcs := []string{
+		`p :: package("a"); d : "a documentation"`,
+		`p :: package("a"); d : { key = "value" }`,
+		`p :: package("a"); R :: record{ a: String; b: "no field" }`,
+		`p :: package("a"); R :: record{ a: String }; a : "not a field"`,
+	}
+	for _, src := range srcs {
+		if _, errs := ParseFile("test.tem", []byte(src)); errs.Len() == 0 {
+			t.Errorf("ParseFile(%s) succeeded unexpectedly", src)
+		}
+	}
+}
+
+func TestAnnotateEveryOrphan(t *testing.T) {
+	src := `
+		p :: package("a")
+		a : "no declaration"
+		b : { key = "value" }
+		R :: record{ x: String; y: "no field" }
+	`
+	_, errs := ParseFile("test.tem", []byte(src))
+	var got []string
+	for !errs.Empty() {
+		err, _ := errs.Pop()
+		if err.Code() != token.CodeAnnotate {
+			t.Errorf("expected code %s got %s for %q", token.CodeAnnotate, err.Code(), err.Message())
+		}
+		got = append(got, err.Message())
+	}
+	expected := []string{
+		"documentation of a has no declaration",
+		"tag of b has no declaration",
+		"documentation of y has no declaration",
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"temlang/tem/ast"
+	"temlang/tem/token"
+)
+
+// attachComments groups the comments and attaches each group to a node.
+// A comment at the end of the last line of a node is the trailing group
+// of the node. A group on the lines right before a node is its leading
+// group. The other groups are only in the comments of the namespace.
+func attachComments(ns *ast.Namespace, comments []token.Token) {
+	if len(comments) == 0 {
+		return
+	}
+	line := func(offset int) int {
+		return ns.LineCol(offset).Line
+	}
+
+	// the nodes are in source order, a node before its children
+	var nodes []ast.Commented
+	ast.Inspect(ns, func(n ast.Node) bool {
+		if c, ok := n.(ast.Commented); ok {
+			nodes = append(nodes, c)
+		}
+		return true
+	})
+
+	var groups, leadings []*ast.CommentGroup
+	var leading *ast.CommentGroup
+	for _, tok := range comments {
+		c := &ast.Comment{
+			Text:     tok.Text(),
+			Position: ast.Position{Start: tok.Start(), End: tok.End()},
+		}
+
+		if n := trailed(nodes, c, line); n != nil {
+			g := &ast.CommentGroup{List: []*ast.Comment{c}, Position: c.Position}
+			ast.CommentsOf(n).Trailing = g
+			groups = append(groups, g)
+			leading = nil
+			continue
+		}
+
+		// a comment after the first line of a node, like the opening
+		// brace of a record, is a group of its own attached to no node
+		if opened(nodes, c, line) {
+			groups = append(groups, &ast.CommentGroup{List: []*ast.Comment{c}, Position: c.Position})
+			leading = nil
+			continue
+		}
+
+		if leading != nil && line(c.Start)-line(leading.End) <= 1 {
+			leading.List = append(leading.List, c)
+			leading.End = c.End
+			continue
+		}
+		leading = &ast.CommentGroup{List: []*ast.Comment{c}, Position: c.Position}
+		groups = append(groups, leading)
+		leadings = append(leadings, leading)
+	}
+
+	for _, g := range leadings {
+		if n := led(nodes, g, line); n != nil {
+			ast.CommentsOf(n).Leading = g
+		}
+	}
+	ns.SetComments(groups)
+}
+
+// trailed returns the node ending on the line of c before c. Of nested
+// nodes ending on that line the one ending last is returned.
+func trailed(nodes []ast.Commented, c *ast.Comment, line func(int) int) ast.Commented {
+	var found ast.Commented
+	for _, n := range nodes {
+		pos := n.Pos()
+		if pos.End > c.Start || line(pos.End) != line(c.Start) {
+			continue
+		}
+		if found == nil || pos.End > found.Pos().End {
+			found = n
+		}
+	}
+	return found
+}
+
+// opened reports whether a node starts on the line of c before c.
+func opened(nodes []ast.Commented, c *ast.Comment, line func(int) int) bool {
+	for _, n := range nodes {
+		if start := n.Pos().Start; start < c.Start && line(start) == line(c.Start) {
+			return true
+		}
+	}
+	return false
+}
+
+// led returns the first node starting after g on the line following g.
+func led(nodes []ast.Commented, g *ast.CommentGroup, line func(int) int) ast.Commented {
+	for _, n := range nodes {
+		pos := n.Pos()
+		if pos.Start < g.End {
+			continue
+		}
+		if line(pos.Start)-line(g.End) == 1 {
+			return n
+		}
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"temlang/tem/ast"
+	"testing"
+)
+
+func TestParseComments(t *testing.T) {
+	src := `// package comment
+p :: package("a") // package trailing
+
+// floating comment
+
+// type comment
+// second line
+T :: type(String)
+R :: record{ // record line
+	// field comment
+	name: String // field trailing
+	}
+T : { key = "value" } // tag trailing
+`
+	file, errs := ParseFile("test.tem", []byte(src), ParseComments())
+	if errs.Len() != 0 {
+		t.Fatal("ParseFile failed unexpectedly")
+	}
+
+	if got := len(file.Comments()); got != 8 {
+		t.Errorf("expected 8 comment groups got %d", got)
+	}
+
+	decls := file.Decls()
+	pkg := decls[0].(*ast.PackageDecl)
+	typ := decls[1].(*ast.TypeDecl)
+	rec := decls[2].(*ast.TypeDecl)
+	tag := decls[3].(*ast.Tag)
+	field := rec.Value.(*ast.RecordType).Fields[0].(*ast.Field)
+
+	tests := []struct {
+		name  string
+		group *ast.CommentGroup
+		want  string
+	}{
+		{"package leading", pkg.Leading, "package comment"},
+		{"package trailing", pkg.Trailing, "package trailing"},
+		{"type leading", typ.Leading, "type comment\nsecond line"},
+		{"type trailing", typ.Trailing, ""},
+		{"record leading", rec.Leading, ""},
+		{"field leading", field.Leading, "field comment"},
+		{"field trailing", field.Trailing, "field trailing"},
+		{"tag trailing", tag.Trailing, "tag trailing"},
+	}
+	for _, tt := range tests {
+		if got := tt.group.Text(); got != tt.want {
+			t.Errorf("%s: expected %q got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestParseWithoutComments(t *testing.T) {
+	src := `// comment
+p :: package("a") // trailing
+`
+	file, errs := ParseFile("test.tem", []byte(src))
+	if errs.Len() != 0 {
+		t.Fatal("ParseFile failed unexpectedly")
+	}
+	if file.Comments() != nil {
+		t.Errorf("expected no comments got %v", file.Comments())
+	}
+	if pkg := file.Decls()[0].(*ast.PackageDecl); pkg.Leading != nil || pkg.Trailing != nil {
+		t.Error("expected no comment attached")
+	}
+}
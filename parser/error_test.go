@@ -1,6 +1,12 @@
 package parser
 
-import "testing"
+import (
+	"fmt"
+	"temlang/tem/ast"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
 
 func TestPackageAfterDeclarationError(t *testing.T) {
 	src := `
@@ -98,3 +104,132 @@ func TestDirectivePlacementErrorOne(t *testing.T) {
 		t.Error("parser succeeded unexpectedly")
 	}
 }
+
+func TestErrorRecovery(t *testing.T) {
+	testcases := []struct {
+		src    string
+		errors []string
+		// decls is the number of declarations that are not bad
+		decls int
+	}{
+		{
+			src: `
+				p :: package("a")
+				x :: pakage("a")
+				t :: type(String)
+			`,
+			errors: []string{"expected 'expression' got ident"},
+			decls:  2,
+		},
+		{
+			src: `
+				p :: package("a")
+				User :: record{
+					name: ;
+					email: String
+					age: Int
+					}
+				t :: type(String)
+			`,
+			errors: []string{"expected 'var type' got ;"},
+			decls:  3,
+		},
+		{
+			src: `
+				p :: package("a")
+				t : { a = ; b = "x" }
+				t :: type(String)
+			`,
+			errors: []string{"expected 'str' got ;"},
+			decls:  3,
+		},
+		{
+			src: `
+				p :: package("a")
+				a :: type(
+				b :: type(String) c :: type(String)
+				d :: type(String)
+			`,
+			errors: []string{"expected ')' got :"},
+			decls:  3,
+		},
+		{
+			src: `
+				p :: package("a")
+				u :: using(p.);
+				t :: type(String)
+			`,
+			errors: []string{"expected 'ident' got )"},
+			decls:  2,
+		},
+		{
+			src: `
+				p :: package("a")
+				u :: templ(u: u){
+					<p (u.)/>
+					<a href= >text/>
+					}
+				t :: type(String)
+			`,
+			errors: []string{"expected 'ident' got )", "expected 'attribute value' got text"},
+			decls:  3,
+		},
+		{
+			src: `
+				p :: package("a")
+				u :: templ(u: u){
+					<p ()/>
+					<p (upper(u.name "x"))/>
+					}
+				t :: type(String)
+			`,
+			errors: []string{"expected 'expression' got )", "expected ')' got str"},
+			decls:  3,
+		},
+		{
+			src: `
+				p :: package("a")
+				u :: templ(u: u){
+					<else <p/>/>
+					<for (f of u.friends) <p/>/>
+					<if u.admin/>
+					}
+				t :: type(String)
+			`,
+			errors: []string{"else without if", "expected 'in' got ident", "expected '(' got text"},
+			decls:  3,
+		},
+		{
+			src: `
+				p :: package("a")
+				t : { a = "x"
+			`,
+			errors: []string{"expected '}' got EOF"},
+			decls:  1,
+		},
+	}
+
+	for i, tc := range testcases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			file, errs := ParseFile("test.tem", []byte(tc.src))
+			var got []string
+			for !errs.Empty() {
+				err, _ := errs.Pop()
+				got = append(got, err.Message())
+			}
+			if diff := cmp.Diff(tc.errors, got); diff != "" {
+				t.Error(diff)
+			}
+
+			decls := 0
+			for _, d := range file.Decls() {
+				if _, ok := d.(*ast.BadDecl); !ok {
+					decls += 1
+				}
+			}
+			if decls != tc.decls {
+				t.Errorf("expected %d declarations got %d", tc.decls, decls)
+			}
+		})
+	}
+}
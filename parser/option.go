@@ -0,0 +1,11 @@
+package parser
+
+type Option func(p *Parser)
+
+// ParseComments keeps the comments of the source. They are grouped and
+// attached to the nearest declaration, field or attribute.
+func ParseComments() Option {
+	return func(p *Parser) {
+		p.parseComments = true
+	}
+}
@@ -0,0 +1,120 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"temlang/tem/ast"
+	"temlang/tem/token"
+)
+
+// ParseDir parses the .tem files of dir, the subdirectories are not
+// parsed. See ParseFiles.
+func ParseDir(ctx context.Context, dir string, opts ...Option) ([]*ast.Namespace, *token.FileSet, []token.Diagnostic, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var filenames []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".tem") {
+			filenames = append(filenames, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return ParseFiles(ctx, filenames, opts...)
+}
+
+// ParseFiles parses the files on a pool of GOMAXPROCS goroutines. The
+// namespaces are in the order of filenames and the file set holds the
+// lines of every file.
+//
+// The diagnostics are the syntax errors of all the files sorted by file
+// and offset, located like ns.Diagnostic does. The error is the error of
+// the first file in order that can't be read, or the error of ctx if it is
+// done before every file is parsed, the other results are nil then.
+func ParseFiles(ctx context.Context, filenames []string, opts ...Option) ([]*ast.Namespace, *token.FileSet, []token.Diagnostic, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	type result struct {
+		ns   *ast.Namespace
+		errs *token.ErrorQueue
+		err  error
+	}
+	results := make([]result, len(filenames))
+
+	// the workers check ctx before each file, the files queued when ctx
+	// is done are skipped
+	jobs := make(chan int, len(filenames))
+	for i := range filenames {
+		jobs <- i
+	}
+	close(jobs)
+	var skipped atomic.Bool
+	var wg sync.WaitGroup
+	workers := min(runtime.GOMAXPROCS(0), len(filenames))
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					skipped.Store(true)
+					continue
+				}
+				src, err := os.ReadFile(filenames[i])
+				if err != nil {
+					results[i].err = err
+					continue
+				}
+				results[i].ns, results[i].errs = ParseFile(filenames[i], src, opts...)
+			}
+		}()
+	}
+
+	wg.Wait()
+	if skipped.Load() {
+		return nil, nil, nil, ctx.Err()
+	}
+
+	namespaces := make([]*ast.Namespace, len(filenames))
+	files := make([]*token.File, len(filenames))
+	for i, r := range results {
+		if r.err != nil {
+			return nil, nil, nil, r.err
+		}
+		namespaces[i] = r.ns
+		files[i] = token.NewFile(r.ns.File(), r.ns.Lines())
+	}
+	fset := token.NewFileSet(files...)
+
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return filenames[order[i]] < filenames[order[j]]
+	})
+	var diags []token.Diagnostic
+	for _, i := range order {
+		r := results[i]
+		var errs []token.Error
+		for !r.errs.Empty() {
+			err, _ := r.errs.Pop()
+			errs = append(errs, err)
+		}
+		sort.SliceStable(errs, func(a, b int) bool {
+			return errs[a].Offset() < errs[b].Offset()
+		})
+		for _, err := range errs {
+			diags = append(diags, r.ns.Diagnostic(err))
+		}
+	}
+	return namespaces, fset, diags, nil
+}
@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseDir(t *testing.T) {
+	namespaces, fset, diags, err := ParseDir(context.Background(), "testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 0 {
+		t.Fatalf("ParseDir failed unexpectedly: %s", diags[0])
+	}
+
+	matches, _ := filepath.Glob(filepath.Join("testdata", "*.tem"))
+	if len(namespaces) != len(matches) {
+		t.Fatalf("expected %d namespaces got %d", len(matches), len(namespaces))
+	}
+	for i, ns := range namespaces {
+		if ns.File() != matches[i] {
+			t.Errorf("namespace %d is %s, expected %s", i, ns.File(), matches[i])
+		}
+		if f := fset.File(ns.File()); f == nil || len(f.Lines()) != len(ns.Lines()) {
+			t.Errorf("file set has no lines for %s", ns.File())
+		}
+	}
+}
+
+func TestParseFilesErrors(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"b.tem": "p :: package(\"b\")\nt :: type(\n",
+		"a.tem": "p :: package(\"a\")\nt :: record{ a: }\nu :: using()\n",
+		"c.tem": "p :: package(\"c\")\n",
+	}
+	var filenames []string
+	for _, name := range []string{"c.tem", "b.tem", "a.tem"} {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, []byte(files[name]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		filenames = append(filenames, filename)
+	}
+
+	namespaces, fset, diags, err := ParseFiles(context.Background(), filenames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(namespaces) != 3 || namespaces[0].File() != filenames[0] || fset == nil {
+		t.Fatalf("expected the namespaces in the order of the files got %v", namespaces)
+	}
+
+	var got []string
+	for _, d := range diags {
+		got = append(got, strings.TrimPrefix(d.String(), dir+string(filepath.Separator)))
+	}
+	expected := []string{
+		"a.tem:2:17: error[syntax]: expected 'var type' got }",
+		"a.tem:3:12: error[syntax]: expected 'ident' got )",
+		"b.tem:3:1: error[syntax]: expected ';' got EOF",
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestParseFilesNotFound(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.tem")
+	_, _, _, err := ParseFiles(context.Background(), []string{"testdata/example.tem", missing})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a not exist error got %v", err)
+	}
+}
+
+// the files queued when the parse is canceled are not parsed, a worker
+// finishes the file it is parsing
+func TestParseFilesCanceledMidway(t *testing.T) {
+	dir := t.TempDir()
+	var filenames []string
+	for i := range 200 {
+		filename := filepath.Join(dir, fmt.Sprintf("%03d.tem", i))
+		if err := os.WriteFile(filename, []byte("p :: package(\"a\")\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		filenames = append(filenames, filename)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var parsed atomic.Int32
+	// the option is applied to the parser of each file, the first file
+	// cancels the parse
+	count := func(p *Parser) {
+		parsed.Add(1)
+		cancel()
+	}
+	namespaces, _, _, err := ParseFiles(ctx, filenames, count)
+	if !errors.Is(err, context.Canceled) || namespaces != nil {
+		t.Fatalf("expected canceled got %v, %v", namespaces, err)
+	}
+	if n, workers := int(parsed.Load()), runtime.GOMAXPROCS(0); n > workers {
+		t.Errorf("expected at most a file per worker got %d files for %d workers", n, workers)
+	}
+}
+
+func TestParseFilesCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	namespaces, _, _, err := ParseFiles(ctx, []string{"testdata/example.tem"})
+	if !errors.Is(err, context.Canceled) || namespaces != nil {
+		t.Errorf("expected canceled got %v, %v", namespaces, err)
+	}
+}
@@ -25,6 +25,7 @@ func (p *Parser) parseGenExpr() Expr {
 		f = p.parseTemplExpr
 	default:
 		offset := p.identOffset()
+		p.errorExpected("expression")
 		return p.badexpr(offset)
 	}
 	return f()
@@ -69,12 +70,16 @@ func (p *Parser) parseUsingExpr() Expr {
 	if !p.expect(token.Using) {
 		return p.badexpr(offset)
 	}
-	var target token.Token
-	var ok bool
-	if target, ok = p.expectSurroundParen(token.Ident); !ok {
-		p.errorExpected("ident")
-		offset := p.offset()
-		return p.badexpr(offset)
+	if !p.expect(token.ParenOpen) {
+		return p.badexpr(p.offset())
+	}
+	// the target is a package or a namespace of a package: using(p.ns)
+	target := p.parseSelectorExpr()
+	if _, ok := target.(badexpr); ok {
+		return p.badexpr(p.offset())
+	}
+	if !p.expect(token.ParenClose) {
+		return p.badexpr(p.offset())
 	}
 	b := p.baseexpr(offset, p.prev.End())
 	return usingexpr{baseexpr: b, target: target}
@@ -109,12 +114,15 @@ func (p *Parser) parseRecordExpr() Expr {
 
 	var fields TreeQueue
 
-	for p.cur.Kind() == token.Ident {
+	for k := p.cur.Kind(); k != token.BraceClose && k != token.EOF; k = p.cur.Kind() {
 		field := p.parseDoc(p.parseVarDecl)
 		fields.Push(field)
-		p.expectSemicolon()
-		if p.cur.Kind() == token.BraceClose {
-			break
+		switch field.(type) {
+		case badtree:
+			p.sync(true)
+		case vartree, listvartree:
+			// docs and tags consume their own semicolon
+			p.expectSemicolon()
 		}
 	}
 	if !p.expect(token.BraceClose) {
@@ -3,11 +3,13 @@ package parser
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"temlang/tem/ast"
 	"temlang/tem/token"
 )
 
-func ParseFile(filename string, src []byte) (*ast.Namespace, *token.ErrorQueue) {
+func ParseFile(filename string, src []byte, opts ...Option) (*ast.Namespace, *token.ErrorQueue) {
 	if src == nil {
 		var err error
 		src, err = os.ReadFile(filename)
@@ -16,12 +18,17 @@ func ParseFile(filename string, src []byte) (*ast.Namespace, *token.ErrorQueue)
 		}
 	}
 
-	name := "" // TODO get the namespace name from the filename
+	// the namespace is named after its file
+	name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
 	file := ast.New(filename, name)
-	p := New(filename, src)
+	p := New(filename, src, opts...)
 	parse(file, &p)
+	annotate(file.Decls(), p.errors)
 
 	file.SetLines(p.Lines())
+	if p.parseComments {
+		attachComments(file, p.comments)
+	}
 	return file, p.errors
 }
 
@@ -29,12 +36,35 @@ func parse(f *ast.Namespace, p *Parser) {
 	var last token.Kind
 
 	for p.cur.Kind() != token.EOF {
+		offset := p.offset()
+		// a declaration is a synchronization point
+		p.panicking = false
 		tree := p.parseDoc(p.parseGenDecl)
-		tree.TreeAst(f)
+		if d, ok := tree.TreeAst().(ast.Decl); ok {
+			f.Add(d)
+		}
+		if t, ok := tree.(pkgtree); ok {
+			if e, ok := t.expr.(pkgexpr); ok {
+				// an invalid string was reported by the tokenizer
+				name, _ := e.name.Unquote()
+				f.SetPackageName(name)
+			}
+		}
 
 		if _, ok := tree.(badtree); ok {
+			// skip the token a bad declaration stopped at to avoid an
+			// infinite loop
+			if p.offset() == offset {
+				p.advance()
+			}
+			p.sync(false)
 			continue
 		}
+		if p.panicking {
+			// the declaration has an error but was parsed up to a
+			// point the parsing can resume at or before
+			p.sync(false)
+		}
 		if _, ok := tree.(doctree); ok {
 			continue
 		}
@@ -63,13 +93,15 @@ func parse(f *ast.Namespace, p *Parser) {
 
 		last = p.lastTreeKind
 	}
+	p.panicking = false
 }
 
 func (p *Parser) parseDoc(f parseDeclSpec) Tree {
+	offset := p.offset()
 	ok := p.matchIdents()
 	if !ok {
 		p.errorExpected("ident")
-		return p.badtree(p.identOffset())
+		return p.badtree(offset)
 	}
 
 	switch k := p.cur.Kind(); k {
@@ -84,6 +116,7 @@ func (p *Parser) parseDoc(f parseDeclSpec) Tree {
 
 func (p *Parser) parseGenDecl() Tree {
 	var dtype token.Token
+	var kind token.Kind
 	var expr Expr
 	var directives token.TokenQueue
 
@@ -103,10 +136,13 @@ declStart:
 
 		expr = p.parseGenExpr()
 
-		// infer the tree based on the expr
+		// the tree is based on the expr, an explicit type annotation is
+		// kept in dtype and checked against the expr by the type checker
+		kind = p.inferTreeFromExpr(expr)
 		if dtype.Kind() == token.Invalid {
-			kind := p.inferTreeFromExpr(expr)
 			dtype = p.emptyToken(kind, dtypeOffset)
+		} else if kind == token.Invalid {
+			kind = dtype.Kind()
 		}
 
 	case token.Package,
@@ -131,6 +167,7 @@ declStart:
 		}
 		p.advance()
 		dtype = p.prev
+		kind = dtype.Kind()
 
 	default:
 		offset := p.identOffset()
@@ -140,16 +177,17 @@ declStart:
 	}
 
 	d := p.decltree(idents, dtype)
+	d.directives = directives
 	p.expectSemicolon()
-	p.lastTreeKind = dtype.Kind()
+	p.lastTreeKind = kind
 
-	return p.createTree(dtype, directives, d, expr)
+	return p.createTree(kind, d, expr)
 }
 
-func (p *Parser) createTree(kind token.Token, directives token.TokenQueue, d decltree, e Expr) Tree {
-	switch kind.Kind() {
+func (p *Parser) createTree(kind token.Kind, d decltree, e Expr) Tree {
+	switch kind {
 	case token.Package:
-		return pkgtree{decltree: d, directives: directives, expr: e}
+		return pkgtree{decltree: d, expr: e}
 	case token.Import:
 		return importtree{decltree: d, expr: e}
 	case token.Using:
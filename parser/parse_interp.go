@@ -0,0 +1,115 @@
+package parser
+
+import "temlang/tem/token"
+
+// The expressions of the interpolations are parsed by precedence
+// climbing. An operand starts with a prefix token, the postfix tokens
+// binding tighter than the current precedence extend it:
+//
+//	upper(u.name)    call(upper, selector(u, name))
+//	(u.friend).name  selector(paren(selector(u, friend)), name)
+
+// prec is the binding power of a postfix token.
+type prec int
+
+const (
+	precLowest prec = iota
+	// precCall binds the arguments of a helper
+	precCall
+	// precSelector binds the name following a dot
+	precSelector
+)
+
+// postfixPrecs are the precedences of the tokens extending an operand.
+var postfixPrecs = map[token.Kind]prec{
+	token.ParenOpen: precCall,
+	token.Dot:       precSelector,
+}
+
+type parsePrefixSpec func() Expr
+
+type parsePostfixSpec func(x Expr) Expr
+
+func (p *Parser) prefixSpec(k token.Kind) parsePrefixSpec {
+	switch k {
+	case token.Ident, token.String:
+		return p.parseOperand
+	case token.ParenOpen:
+		return p.parseParenExpr
+	}
+	return nil
+}
+
+func (p *Parser) postfixSpec(k token.Kind) parsePostfixSpec {
+	switch k {
+	case token.Dot:
+		return p.parseSelector
+	case token.ParenOpen:
+		return p.parseCallExpr
+	}
+	return nil
+}
+
+// parseExpr parses an expression whose postfix tokens bind tighter than
+// min.
+func (p *Parser) parseExpr(min prec) Expr {
+	prefix := p.prefixSpec(p.cur.Kind())
+	if prefix == nil {
+		offset := p.offset()
+		p.errorExpected("expression")
+		return p.badexpr(offset)
+	}
+	x := prefix()
+	for {
+		prec, ok := postfixPrecs[p.cur.Kind()]
+		if !ok || prec <= min {
+			return x
+		}
+		if _, ok := x.(badexpr); ok {
+			return x
+		}
+		x = p.postfixSpec(p.cur.Kind())(x)
+	}
+}
+
+func (p *Parser) parseOperand() Expr {
+	p.advance()
+	return litexpr(p.prev)
+}
+
+func (p *Parser) parseParenExpr() Expr {
+	offset := p.offset()
+	p.advance()
+	x := p.parseExpr(precLowest)
+	if !p.expect(token.ParenClose) {
+		return p.badexpr(offset)
+	}
+	b := p.baseexpr(offset, p.prev.End())
+	return parenexpr{baseexpr: b, x: x}
+}
+
+func (p *Parser) parseSelector(x Expr) Expr {
+	p.advance()
+	sel := p.cur
+	if !p.expect(token.Ident) {
+		return p.badexpr(x.Pos().Start)
+	}
+	b := p.baseexpr(x.Pos().Start, sel.End())
+	return selectorexpr{baseexpr: b, x: x, sel: sel}
+}
+
+func (p *Parser) parseCallExpr(fun Expr) Expr {
+	p.advance()
+	var args []Expr
+	for p.cur.Kind() != token.ParenClose && p.cur.Kind() != token.EOF {
+		args = append(args, p.parseExpr(precLowest))
+		if !p.match(token.Comma) {
+			break
+		}
+	}
+	if !p.expect(token.ParenClose) {
+		return p.badexpr(fun.Pos().Start)
+	}
+	b := p.baseexpr(fun.Pos().Start, p.prev.End())
+	return callexpr{baseexpr: b, fun: fun, args: args}
+}
@@ -2,29 +2,35 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"temlang/tem/dsa/queue"
 	"temlang/tem/token"
 	"temlang/tem/tokenizer"
+	"unicode"
 )
 
-func New(filename string, src []byte) Parser {
-	tok := tokenizer.New(filename, src)
+func New(filename string, src []byte, opts ...Option) Parser {
+	errors := &token.ErrorQueue{}
+	// the tokenizer errors are reported with the parser errors
+	tokErr := func(offset int, ch string, msg string) {
+		defaultErrorHandler(errors, offset, fmt.Sprintf("%s %q", msg, ch))
+	}
+	tok := tokenizer.New(filename, src, tokenizer.SetErrorHandler(tokErr))
 	p := Parser{
 		filename:  filename,
 		tokenizer: tok,
 		cur:       token.Token{},
-		errors:    &token.ErrorQueue{},
+		errors:    errors,
 	}
-	p.error = func(offset int, msg string) {
-		defaultErrorHandler(p.errors, offset, msg)
+	for _, opt := range opts {
+		opt(&p)
 	}
 	p.advance()
 	return p
 }
 
 func defaultErrorHandler(errors *token.ErrorQueue, offset int, msg string) {
-	err := token.NewError(offset, msg)
-	// log.Printf("%s", err)
+	err := token.NewError(offset, msg).WithCode(token.CodeSyntax)
 	errors.Push(err)
 }
 
@@ -37,14 +43,29 @@ func queueOffset(q token.TokenQueue) int {
 }
 
 type Parser struct {
-	tokenizer    tokenizer.Tokenizer
-	filename     string
-	cur          token.Token
-	prev         token.Token
+	tokenizer tokenizer.Tokenizer
+	filename  string
+	cur       token.Token
+	prev      token.Token
+	// next is the token after cur once peeked
+	next         *token.Token
 	lastTreeKind token.Kind
 	idents       *token.TokenQueue
 	errors       *token.ErrorQueue
-	error        func(offset int, msg string)
+	// panicking is set by an error and cleared when the parser syncs,
+	// the errors in between are follow-on errors and are not reported
+	panicking bool
+
+	parseComments bool
+	comments      []token.Token
+}
+
+func (p *Parser) error(offset int, msg string) {
+	if p.panicking {
+		return
+	}
+	p.panicking = true
+	defaultErrorHandler(p.errors, offset, msg)
 }
 
 func (p *Parser) errorExpected(msg string) {
@@ -54,6 +75,10 @@ func (p *Parser) errorExpected(msg string) {
 	p.error(offset, str)
 }
 
+func (p *Parser) errorf(offset int, format string, args ...any) {
+	p.error(offset, fmt.Sprintf(format, args...))
+}
+
 func (p *Parser) expectSemicolon() {
 	if k := p.cur.Kind(); k != token.BraceClose {
 		p.expect(token.Semicolon)
@@ -73,10 +98,14 @@ func (p *Parser) identOffset() int {
 
 func (p *Parser) Mark() func() {
 	prev := p.cur
+	next := p.next
+	comments := len(p.comments)
 	reset := p.tokenizer.Mark()
 	return func() {
 		reset()
 		p.cur = prev
+		p.next = next
+		p.comments = p.comments[:comments]
 	}
 }
 
@@ -85,6 +114,9 @@ func (p *Parser) skipNewlineAndComment() token.Token {
 	for {
 		next = p.tokenizer.Next()
 		kind := next.Kind()
+		if kind == token.Comment && p.parseComments {
+			p.comments = append(p.comments, next)
+		}
 		if kind != token.EOL && kind != token.Comment {
 			break
 		}
@@ -96,12 +128,65 @@ func (p *Parser) advance() bool {
 	if p.cur.Kind() == token.EOF {
 		return false
 	}
-	tok := p.skipNewlineAndComment()
+	tok := p.peek()
+	p.next = nil
 	p.prev = p.cur
 	p.cur = tok
 	return true
 }
 
+// peek returns the token following the current token. The token is
+// scanned once, peeking does not report the errors of the tokenizer
+// twice.
+func (p *Parser) peek() token.Token {
+	if p.next == nil {
+		tok := p.skipNewlineAndComment()
+		p.next = &tok
+	}
+	return *p.next
+}
+
+// sync skips the tokens following an error to the next point parsing can
+// resume at and reports the errors again. Parsing resumes after a
+// semicolon, at an ident followed by a colon or a comma starting a
+// declaration, or in a block at its closing brace. The tokens between
+// braces are skipped as a whole.
+func (p *Parser) sync(block bool) {
+	defer func() { p.panicking = false }()
+
+	depth := 0
+	for {
+		switch p.cur.Kind() {
+		case token.EOF:
+			return
+		case token.BraceOpen:
+			depth += 1
+		case token.BraceClose:
+			if depth == 0 && block {
+				return
+			}
+			depth = max(depth-1, 0)
+		case token.Semicolon:
+			if depth == 0 {
+				p.advance()
+				return
+			}
+		case token.Ident:
+			if depth == 0 && !block && p.declStart() {
+				return
+			}
+		}
+		p.advance()
+	}
+}
+
+// declStart reports whether the current ident is followed by a colon or a
+// comma.
+func (p *Parser) declStart() bool {
+	k := p.peek().Kind()
+	return k == token.Colon || k == token.Comma
+}
+
 func (p *Parser) match(tok token.Kind) bool {
 	if p.cur.Kind() != tok {
 		return false
@@ -241,6 +326,9 @@ func (p *Parser) parseIdents(f parseDeclSpec) Tree {
 }
 
 func (p *Parser) parseVarDecl() Tree {
+	if start := p.offset(); p.match(token.BracketOpen) {
+		return p.parseListVarDecl(start)
+	}
 	if !p.match(token.Ident) && !p.match(token.Type) {
 		p.errorExpected("var type")
 		return p.badtree(p.identOffset())
@@ -252,6 +340,20 @@ func (p *Parser) parseVarDecl() Tree {
 	return vartree(d)
 }
 
+// parseListVarDecl parses the element type of a list type following its
+// opening bracket at start.
+//
+//	friends: []User
+func (p *Parser) parseListVarDecl(start int) Tree {
+	if !p.expect(token.BracketClose) || !p.expect(token.Ident) {
+		return p.badtree(p.identOffset())
+	}
+	// NOTE: assume p.idents is not nil
+	idents := *p.idents
+	d := p.decltree(idents, p.prev)
+	return listvartree{decltree: d, start: start}
+}
+
 func (p *Parser) parseParamDecl() TreeQueue {
 	offset := p.offset()
 	if !p.expect(token.ParenOpen) {
@@ -297,31 +399,38 @@ func (p *Parser) parseAttrDecl() Tree {
 }
 
 func (p *Parser) parseTagDecl() Tree {
+	// NOTE assume p.idents is not nil at this point
+	// the attributes overwrite p.idents
+	idents := *p.idents
+	offset := p.identOffset()
+
 	if !p.expect(token.BraceOpen) {
 		p.errorExpected("{")
-		return p.badtree(p.identOffset())
+		return p.badtree(offset)
 	}
 
 	var attrs TreeQueue
 	for {
 		attr := p.parseAttrDecl()
 		attrs.Push(attr)
-		p.expectSemicolon()
-		if p.cur.Kind() == token.BraceClose {
+		if _, ok := attr.(badtree); ok {
+			p.sync(true)
+		} else {
+			p.expectSemicolon()
+		}
+		if k := p.cur.Kind(); k == token.BraceClose || k == token.EOF {
 			break
 		}
 	}
 
 	if !p.expect(token.BraceClose) {
-		p.errorExpected("{")
-		return p.badtree(p.identOffset())
+		p.errorExpected("}")
+		return p.badtree(offset)
 	}
 
+	loc := Position{Start: offset, End: p.prev.End()}
 	p.expectSemicolon()
 
-	// NOTE assume p.idents is not nil at this point
-	idents := *p.idents
-	loc := p.locationStartingAt(p.identOffset())
 	tree := tagtree{
 		idents:   idents,
 		attrs:    attrs,
@@ -331,7 +440,264 @@ func (p *Parser) parseTagDecl() Tree {
 }
 
 func (p *Parser) parseElements() TreeQueue {
-	// TODO: parse template elements,text, and expression
 	var ts TreeQueue
-	return ts
+	for {
+		switch p.cur.Kind() {
+		case token.BraceClose, token.EOF:
+			return ts
+		default:
+			// the markups of a templ body are synchronization points
+			p.panicking = false
+			if t := p.parseMarkup(); t != nil {
+				ts.Push(t)
+			}
+		}
+	}
+}
+
+// parseMarkup returns nil for text that only separates elements.
+func (p *Parser) parseMarkup() Tree {
+	switch p.cur.Kind() {
+	case token.ElementOpen:
+		switch p.cur.Text() {
+		case "<if":
+			return p.parseIf()
+		case "<for":
+			return p.parseFor()
+		case "<else":
+			offset := p.offset()
+			p.error(offset, "else without if")
+			p.advance()
+			p.parseChildren()
+			return p.badtree(offset)
+		}
+		return p.parseElement()
+	case token.InterpOpen:
+		return p.parseInterp()
+	case token.Text:
+		return p.parseText()
+	default:
+		offset := p.offset()
+		p.error(offset, fmt.Sprintf("unexpected %s", p.cur.Kind()))
+		p.advance() // advance to avoid infinite loop
+		return p.badtree(offset)
+	}
+}
+
+func (p *Parser) parseElement() Tree {
+	offset := p.offset()
+	open := p.cur
+	if !p.expect(token.ElementOpen) {
+		return p.badtree(offset)
+	}
+
+	// the element name follows the opening angle bracket
+	name := token.NewWithText(token.Ident, open.Text()[1:], open.Start()+1, open.End())
+	attrs := p.parseElementAttrs()
+
+	children, ok := p.parseChildren()
+	if !ok {
+		return p.badtree(offset)
+	}
+	pos := Position{Start: offset, End: p.prev.End()}
+	return elemtree{name: name, attrs: attrs, children: children, Position: pos}
+}
+
+// parseChildren parses the children of an element up to and including
+// the self close ending it.
+func (p *Parser) parseChildren() (TreeQueue, bool) {
+	var children TreeQueue
+	for {
+		switch p.cur.Kind() {
+		case token.SelfClose:
+			p.advance()
+			return children, true
+		case token.BraceClose, token.EOF:
+			p.errorExpected(token.SelfClose.String())
+			return children, false
+		default:
+			if t := p.parseMarkup(); t != nil {
+				children.Push(t)
+			}
+		}
+	}
+}
+
+// skipHeadSpace skips the space between the head of an if or a for and
+// its children like the space following the attributes of an element.
+func (p *Parser) skipHeadSpace() {
+	if p.cur.Kind() != token.Text {
+		return
+	}
+	text := p.cur.Text()
+	trimmed := strings.TrimLeftFunc(text, unicode.IsSpace)
+	if trimmed == "" {
+		p.advance()
+		return
+	}
+	start := p.cur.Start() + len(text) - len(trimmed)
+	p.cur = token.NewWithText(token.Text, trimmed, start, p.cur.End())
+}
+
+// parseIf parses an if element and the else element following it.
+//
+//	<if (u.admin) <p admin/>/>
+//	<else <p user/>/>
+func (p *Parser) parseIf() Tree {
+	offset := p.offset()
+	p.advance()
+	cond := p.parseInterpExpr()
+	p.skipHeadSpace()
+	children, ok := p.parseChildren()
+	if _, bad := cond.(badexpr); bad || !ok {
+		return p.badtree(offset)
+	}
+	t := iftree{cond: cond, children: children, Position: Position{Start: offset, End: p.prev.End()}}
+
+	// only the space between the elements can separate an else from its if
+	prev, reset := p.prev, p.Mark()
+	for p.cur.Kind() == token.Text && strings.TrimSpace(p.cur.Text()) == "" {
+		p.advance()
+	}
+	if p.cur.Kind() != token.ElementOpen || p.cur.Text() != "<else" {
+		reset()
+		p.prev = prev
+		return t
+	}
+	elseOffset := p.offset()
+	p.advance()
+	children, ok = p.parseChildren()
+	if !ok {
+		return p.badtree(offset)
+	}
+	t.els = &elsetree{children: children, Position: Position{Start: elseOffset, End: p.prev.End()}}
+	t.End = p.prev.End()
+	return t
+}
+
+// parseFor parses a for element, the index is optional.
+//
+//	<for (i, f in u.friends) <p (i) (f.name)/>/>
+func (p *Parser) parseFor() Tree {
+	offset := p.offset()
+	p.advance()
+	t, ok := p.parseForHead()
+	p.skipHeadSpace()
+	children, closed := p.parseChildren()
+	if !ok || !closed {
+		return p.badtree(offset)
+	}
+	t.children = children
+	t.Position = Position{Start: offset, End: p.prev.End()}
+	return t
+}
+
+func (p *Parser) parseForHead() (fortree, bool) {
+	var t fortree
+	if !p.expect(token.InterpOpen) {
+		return t, false
+	}
+	t.value = p.cur
+	if !p.expect(token.Ident) {
+		return t, false
+	}
+	if p.match(token.Comma) {
+		t.index, t.value = t.value, p.cur
+		if !p.expect(token.Ident) {
+			return t, false
+		}
+	}
+	if p.cur.Kind() != token.Ident || p.cur.Text() != "in" {
+		p.errorExpected("in")
+		return t, false
+	}
+	p.advance()
+	t.x = p.parseExpr(precLowest)
+	if _, bad := t.x.(badexpr); bad {
+		return t, false
+	}
+	return t, p.expect(token.InterpClose)
+}
+
+func (p *Parser) parseElementAttrs() TreeQueue {
+	var attrs TreeQueue
+	for p.cur.Kind() == token.Ident {
+		key := p.cur
+		p.advance()
+		if !p.expect(token.Eq) {
+			return attrs
+		}
+
+		var val Expr
+		switch p.cur.Kind() {
+		case token.String:
+			p.advance()
+			val = litexpr(p.prev)
+		case token.InterpOpen:
+			val = p.parseInterpExpr()
+		default:
+			p.errorExpected("attribute value")
+			val = p.badexpr(p.offset())
+		}
+
+		var idents token.TokenQueue
+		idents.Push(key)
+		pos := Position{Start: key.Start(), End: p.prev.End()}
+		attrs.Push(attrtree{idents: idents, value: val, Position: pos})
+	}
+	return attrs
+}
+
+func (p *Parser) parseInterp() Tree {
+	offset := p.offset()
+	expr := p.parseInterpExpr()
+	pos := Position{Start: offset, End: p.prev.End()}
+	return interptree{expr: expr, Position: pos}
+}
+
+func (p *Parser) parseInterpExpr() Expr {
+	offset := p.offset()
+	if !p.expect(token.InterpOpen) {
+		return p.badexpr(offset)
+	}
+	expr := p.parseExpr(precLowest)
+	if !p.expect(token.InterpClose) {
+		return p.badexpr(offset)
+	}
+	return expr
+}
+
+func (p *Parser) parseSelectorExpr() Expr {
+	offset := p.offset()
+	if !p.expect(token.Ident) {
+		return p.badexpr(offset)
+	}
+
+	var expr Expr = litexpr(p.prev)
+	for p.match(token.Dot) {
+		sel := p.cur
+		if !p.expect(token.Ident) {
+			return p.badexpr(offset)
+		}
+		b := p.baseexpr(offset, sel.End())
+		expr = selectorexpr{baseexpr: b, x: expr, sel: sel}
+	}
+	return expr
+}
+
+func (p *Parser) parseText() Tree {
+	before := p.prev.Kind()
+	text := p.cur
+	p.advance()
+
+	// whitespace is only meaningful between interpolations, anywhere
+	// else it separates elements
+	if strings.TrimSpace(text.Text()) == "" {
+		if before != token.InterpClose || p.cur.Kind() != token.InterpOpen {
+			return nil
+		}
+	}
+
+	pos := Position{Start: text.Start(), End: text.End()}
+	return texttree{text: text.Text(), Position: pos}
 }
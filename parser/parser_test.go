@@ -1,13 +1,19 @@
 package parser
 
 import (
+	"flag"
 	"os"
 	"path/filepath"
 	"strings"
 	"temlang/tem/ast"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
+var update = flag.Bool("update", false, "update the golden files")
+
 func TestParseFile(t *testing.T) {
 	path := "./testdata"
 	list, err := os.ReadDir(path)
@@ -72,6 +78,39 @@ func TestTopLevelVarDeclaration(t *testing.T) {
 	}
 }
 
+func TestNamespaceNames(t *testing.T) {
+	src := "p :: package(\"home\")"
+	file, errs := ParseFile(filepath.Join("views", "user.tem"), []byte(src))
+	if errs.Len() != 0 {
+		t.Fatal("ParseFile failed unexpectedly")
+	}
+	if file.Name() != "user" {
+		t.Errorf("expected namespace user got %q", file.Name())
+	}
+	if file.PackageName() != "home" {
+		t.Errorf("expected package home got %q", file.PackageName())
+	}
+}
+
+func TestUsingSelector(t *testing.T) {
+	src := "p :: package(\"a\"); Button :: using(p.widgets)"
+	file, errs := ParseFile("test.tem", []byte(src))
+	if errs.Len() != 0 {
+		t.Fatal("ParseFile failed unexpectedly")
+	}
+	d, ok := file.Decls()[1].(*ast.UsingDecl)
+	if !ok {
+		t.Fatalf("expected using declaration got %#v", file.Decls()[1])
+	}
+	sel, ok := d.Value.(*ast.UsingExpr).Target.(*ast.SelectorExpr)
+	if !ok {
+		t.Fatalf("expected selector got %#v", d.Value.(*ast.UsingExpr).Target)
+	}
+	if x, ok := sel.X.(*ast.Ident); !ok || x.Name != "p" || sel.Sel.Name != "widgets" {
+		t.Errorf("expected p.widgets got %#v", sel)
+	}
+}
+
 func TestAstPrinter(t *testing.T) {
 	path := "./testdata"
 	list, err := os.ReadDir(path)
@@ -99,3 +138,332 @@ func TestAstPrinter(t *testing.T) {
 		}
 	}
 }
+
+// TestAstPrinterGolden compares the s-expression of each file of testdata
+// with the .sexpr golden file of the same name node by node.
+func TestAstPrinterGolden(t *testing.T) {
+	files, err := filepath.Glob("./testdata/*.tem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, filename := range files {
+		t.Run(filepath.Base(filename), func(t *testing.T) {
+			file, _ := ParseFile(filename, nil)
+			printed := ast.PrintSExpr(file)
+
+			golden := strings.TrimSuffix(filename, ".tem") + ".sexpr"
+			if *update {
+				if err := os.WriteFile(golden, []byte(printed), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			src, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected, err := ast.ParseSExpr(string(src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ast.ParseSExpr(printed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(expected, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestTemplElements(t *testing.T) {
+	src := `
+		p :: package("a")
+
+		User :: templ(u: User) {
+			<div
+				<p Username: (u.name)/>
+				/>
+			}
+	`
+
+	filename := "test.tem"
+	file, errs := ParseFile(filename, []byte(src))
+	if errs.Len() != 0 {
+		t.Fatalf("ParseFile(%v) failed unexpectedly", filename)
+	}
+
+	exprs, err := ast.ParseSExpr(ast.PrintSExpr(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := ast.ParseSExpr(`
+		(elements
+		  (element
+		    (name (identifier) 'div')
+		    (attributes)
+		    (children
+		      (element
+		        (name (identifier) 'p')
+		        (attributes)
+		        (children
+		          (text) 'Username: '
+		          (interpolation
+		            (expr
+		              (selector_expr
+		                (identifier) 'u'
+		                (identifier) 'name'))))))))
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// templ_declaration > expr > templ_expr > elements
+	elements := exprs[1].Children[3].Children[0].Children[1]
+	noPos := cmpopts.IgnoreFields(ast.SExpr{}, "Start", "End")
+	if diff := cmp.Diff(expected[0], elements, noPos); diff != "" {
+		t.Error(diff)
+	}
+}
+
+// the whitespace separating interpolations is text, the whitespace around
+// the elements is dropped
+func TestTemplWhitespace(t *testing.T) {
+	src := `
+		p :: package("a")
+
+		User :: templ(u: User) {
+			<p (u.name) (u.email)/> <br/>
+			<b  a  b  />
+			}
+	`
+
+	filename := "test.tem"
+	file, errs := ParseFile(filename, []byte(src))
+	if errs.Len() != 0 {
+		t.Fatalf("ParseFile(%v) failed unexpectedly", filename)
+	}
+
+	exprs, err := ast.ParseSExpr(ast.PrintSExpr(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := ast.ParseSExpr(`
+		(elements
+		  (element
+		    (name (identifier) 'p')
+		    (attributes)
+		    (children
+		      (interpolation
+		        (expr
+		          (selector_expr
+		            (identifier) 'u'
+		            (identifier) 'name')))
+		      (text) ' '
+		      (interpolation
+		        (expr
+		          (selector_expr
+		            (identifier) 'u'
+		            (identifier) 'email')))))
+		  (element
+		    (name (identifier) 'br')
+		    (attributes)
+		    (children))
+		  (element
+		    (name (identifier) 'b')
+		    (attributes)
+		    (children
+		      (text) 'a  b  ')))
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// templ_declaration > expr > templ_expr > elements
+	elements := exprs[1].Children[3].Children[0].Children[1]
+	noPos := cmpopts.IgnoreFields(ast.SExpr{}, "Start", "End")
+	if diff := cmp.Diff(expected[0], elements, noPos); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestInterpExprs(t *testing.T) {
+	testcases := []struct {
+		src      string
+		expected string
+	}{
+		{
+			src:      `(u.friend.name)`,
+			expected: `(selector_expr (selector_expr (identifier) 'u' (identifier) 'friend') (identifier) 'name')`,
+		},
+		{
+			src:      `("hi")`,
+			expected: `(string) '"hi"'`,
+		},
+		{
+			src: `(upper(u.name))`,
+			expected: `
+				(call_expr
+				  (function (identifier) 'upper')
+				  (arguments (selector_expr (identifier) 'u' (identifier) 'name')))`,
+		},
+		{
+			src: `(join(lower(u.name), "-",))`,
+			expected: `
+				(call_expr
+				  (function (identifier) 'join')
+				  (arguments
+				    (call_expr
+				      (function (identifier) 'lower')
+				      (arguments (selector_expr (identifier) 'u' (identifier) 'name')))
+				    (string) '"-"'))`,
+		},
+		{
+			// the selector binds tighter than the call
+			src: `((u.friend).name)`,
+			expected: `
+				(selector_expr
+				  (parenthesized_expr (selector_expr (identifier) 'u' (identifier) 'friend'))
+				  (identifier) 'name')`,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.src, func(t *testing.T) {
+			src := "p :: package(\"a\")\nt :: templ(u: t){ <p " + tc.src + "/> }"
+			file, errs := ParseFile("test.tem", []byte(src))
+			if !errs.Empty() {
+				err, _ := errs.Pop()
+				t.Fatalf("ParseFile failed unexpectedly: %s", err)
+			}
+			exprs, err := ast.ParseSExpr(ast.PrintSExpr(file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// elements > element > children > interpolation > expr
+			elements := exprs[1].Children[3].Children[0].Children[1]
+			got := elements.Children[0].Children[2].Children[0].Children[0].Children
+			expected, err := ast.ParseSExpr(tc.expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			noPos := cmpopts.IgnoreFields(ast.SExpr{}, "Start", "End")
+			if diff := cmp.Diff(expected, got, noPos); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestControlFlow(t *testing.T) {
+	src := `
+		p :: package("a")
+		User :: record{ admin: Bool; friends: []User }
+		User :: templ(u: User) {
+			<if (u.admin) admin/>
+			<else user/>
+			<for (i, f in u.friends) (i)/>
+			<for (f in u.friends)/>
+			}
+	`
+	file, errs := ParseFile("test.tem", []byte(src))
+	if !errs.Empty() {
+		err, _ := errs.Pop()
+		t.Fatalf("ParseFile failed unexpectedly: %s", err)
+	}
+
+	exprs, err := ast.ParseSExpr(ast.PrintSExpr(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := ast.ParseSExpr(`
+		(var_declaration
+		  (identifiers (identifier) 'friends')
+		  (type (list_type (identifier) 'User')))
+		(elements
+		  (if_element
+		    (condition (selector_expr (identifier) 'u' (identifier) 'admin'))
+		    (children (text) 'admin')
+		    (else_element (children (text) 'user')))
+		  (for_element
+		    (index (identifier) 'i')
+		    (value (identifier) 'f')
+		    (range (selector_expr (identifier) 'u' (identifier) 'friends'))
+		    (children (interpolation (expr (identifier) 'i'))))
+		  (for_element
+		    (value (identifier) 'f')
+		    (range (selector_expr (identifier) 'u' (identifier) 'friends'))
+		    (children)))
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// type_declaration > expr > record_expr > fields
+	friends := exprs[1].Children[3].Children[0].Children[0].Children[1]
+	// templ_declaration > expr > templ_expr > elements
+	elements := exprs[2].Children[3].Children[0].Children[1]
+	noPos := cmpopts.IgnoreFields(ast.SExpr{}, "Start", "End")
+	if diff := cmp.Diff(expected, []*ast.SExpr{friends, elements}, noPos); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestParseFileAst(t *testing.T) {
+	src := `
+		p :: package("a")
+		i :: import("b")
+		A, B :: using(i)
+
+		T : "documentation"
+		T : { key = "value" }
+		T :: type(A)
+		R :: record{ name: String }
+		R :: templ(r: R){ <p (r.name)/> }
+	`
+
+	filename := "test.tem"
+	file, errs := ParseFile(filename, []byte(src))
+	if errs.Len() != 0 {
+		t.Fatalf("ParseFile(%v) failed unexpectedly", filename)
+	}
+
+	decls := file.Decls()
+	if len(decls) != 8 {
+		t.Fatalf("expected 8 declarations got %d", len(decls))
+	}
+
+	if d, ok := decls[0].(*ast.PackageDecl); !ok || d.Names[0].Name != "p" || d.Type != nil {
+		t.Errorf("expected package declaration got %#v", decls[0])
+	}
+	if _, ok := decls[1].(*ast.ImportDecl); !ok {
+		t.Errorf("expected import declaration got %#v", decls[1])
+	}
+	if d, ok := decls[2].(*ast.UsingDecl); !ok || len(d.Names) != 2 {
+		t.Errorf("expected using declaration got %#v", decls[2])
+	}
+	if _, ok := decls[3].(*ast.Doc); !ok {
+		t.Errorf("expected doc got %#v", decls[3])
+	}
+	if d, ok := decls[4].(*ast.Tag); !ok || d.Names[0].Name != "T" || d.Attrs[0].Names[0].Name != "key" {
+		t.Errorf("expected tag got %#v", decls[4])
+	}
+	if d, ok := decls[5].(*ast.TypeDecl); !ok {
+		t.Errorf("expected type declaration got %#v", decls[5])
+	} else if e, ok := d.Value.(*ast.TypeExpr); !ok || e.Target.Name != "A" {
+		t.Errorf("expected type expression got %#v", d.Value)
+	}
+	if d, ok := decls[6].(*ast.TypeDecl); !ok {
+		t.Errorf("expected type declaration got %#v", decls[6])
+	} else if e, ok := d.Value.(*ast.RecordType); !ok || len(e.Fields) != 1 {
+		t.Errorf("expected record type got %#v", d.Value)
+	}
+	if d, ok := decls[7].(*ast.TemplDecl); !ok {
+		t.Errorf("expected templ declaration got %#v", decls[7])
+	} else if e, ok := d.Value.(*ast.TemplLit); !ok || len(e.Params) != 1 || len(e.Body) != 1 {
+		t.Errorf("expected templ literal got %#v", d.Value)
+	} else if el, ok := e.Body[0].(*ast.Element); !ok || el.Name.Name != "p" {
+		t.Errorf("expected element got %#v", e.Body[0])
+	}
+}
@@ -73,6 +73,8 @@ var valids = []string{
 	`p :: package("m");   t :: record{ a: String; b: String };`,
 	`p :: package("m");   t :: #d record{ a: String; b: String };`,
 	`p :: package("m");   t :: #d #d2 record{ a: String; b: String };`,
+	`p :: package("m");   t :: record{ a: "doc"; a: { k = "v" }; a: String }`,
+	"p :: package(\"m\"); t :: record{\na: \"doc\"\na: { k = \"v\" }\na: String\n}\n",
 	// record type with type annotation
 	"p :: package(\"m\"); t: type: record{ a: String\n}\n",
 	`p :: package("m");   t: type: record{ a: String }`,
@@ -89,6 +91,27 @@ var valids = []string{
 	"p :: package(\"m\"); c: templ: templ(m: Model){}\n",
 	`p :: package("m");   c: templ: templ(m: Model){}`,
 	`p :: package("m");   c: templ: templ(m: Model){};`,
+	// template elements
+	`p :: package("m");   c :: templ(m: Model){ <p/> }`,
+	`p :: package("m");   c :: templ(m: Model){ <p text/> }`,
+	`p :: package("m");   c :: templ(m: Model){ <p (m)/> }`,
+	`p :: package("m");   c :: templ(m: Model){ <p (m.name)/> }`,
+	`p :: package("m");   c :: templ(m: Model){ <p Name: (m.name)/> }`,
+	`p :: package("m");   c :: templ(m: Model){ <p <b bold/> text/> }`,
+	`p :: package("m");   c :: templ(m: Model){ <a href="/"/> }`,
+	`p :: package("m");   c :: templ(m: Model){ <a href="/" link/> }`,
+	`p :: package("m");   c :: templ(m: Model){ <a href=(m.url) link/> }`,
+	`p :: package("m");   c :: templ(m: Model){ <p/> <p/> }`,
+	`p :: package("m");   c :: templ(m: Model){ <p Hello, (m.name)! It's 5 > 4; />}`,
+	"p :: package(\"m\"); c :: templ(m: Model){\n<div\n<p one/>\n<p two/>\n/>\n}\n",
+}
+
+var invalids = []string{
+	`p :: package("m");   c :: templ(m: Model){ <p }`,
+	`p :: package("m");   c :: templ(m: Model){ < /> }`,
+	`p :: package("m");   c :: templ(m: Model){ <p (m./> }`,
+	`p :: package("m");   c :: templ(m: Model){ <a href=/> }`,
+	`p :: package("m");   c :: templ(m: Model){ /> }`,
 }
 
 func TestValids(t *testing.T) {
@@ -102,3 +125,15 @@ func TestValids(t *testing.T) {
 		})
 	}
 }
+
+func TestInvalids(t *testing.T) {
+	const filename = "invalid.tem"
+	for i, src := range invalids {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			_, errs := parser.ParseFile(filename, []byte(src))
+			if errs.Len() == 0 {
+				t.Errorf("ParseFile(%s) succeeded unexpectedly", filename)
+			}
+		})
+	}
+}
@@ -9,7 +9,7 @@ import (
 type TreeQueue = queue.Queue[Tree]
 
 type Tree interface {
-	TreeAst(*ast.Namespace)
+	TreeAst() ast.Node
 	Pos() Position
 }
 
@@ -18,15 +18,15 @@ type badtree struct {
 }
 
 type decltree struct {
-	idents token.TokenQueue
-	dtype  token.Token
+	idents     token.TokenQueue
+	dtype      token.Token
+	directives token.TokenQueue
 	Position
 }
 
 type pkgtree struct {
 	decltree
-	directives token.TokenQueue
-	expr       Expr
+	expr Expr
 }
 
 type importtree struct {
@@ -51,6 +51,14 @@ type templtree struct {
 
 type vartree decltree
 
+// listvartree is a var declared with a list type, dtype is the element
+// type.
+type listvartree struct {
+	decltree
+	// start is the offset of the list type
+	start int
+}
+
 type tagtree struct {
 	idents token.TokenQueue
 	attrs  TreeQueue
@@ -59,7 +67,46 @@ type tagtree struct {
 
 type attrtree struct {
 	idents token.TokenQueue
-	value  litexpr
+	value  Expr
+	Position
+}
+
+type elemtree struct {
+	name     token.Token
+	attrs    TreeQueue
+	children TreeQueue
+	Position
+}
+
+type iftree struct {
+	cond     Expr
+	children TreeQueue
+	// els is nil without an else element
+	els *elsetree
+	Position
+}
+
+type elsetree struct {
+	children TreeQueue
+	Position
+}
+
+type fortree struct {
+	// index is an invalid token if the index is not bound
+	index    token.Token
+	value    token.Token
+	x        Expr
+	children TreeQueue
+	Position
+}
+
+type texttree struct {
+	text string
+	Position
+}
+
+type interptree struct {
+	expr Expr
 	Position
 }
 
@@ -71,7 +118,7 @@ type doctree struct {
 }
 
 type Expr interface {
-	ExprAst(*ast.Namespace)
+	ExprAst() ast.Expr
 	Pos() Position
 }
 
@@ -95,7 +142,7 @@ type importexpr struct {
 
 type usingexpr struct {
 	baseexpr
-	target token.Token
+	target Expr
 }
 
 type typeexpr struct {
@@ -114,70 +161,21 @@ type templexpr struct {
 	elements TreeQueue
 }
 
-type litexpr token.Token
-
-func (t badtree) TreeAst(n *ast.Namespace) {
-	n.Add(t)
-}
-
-func (t pkgtree) TreeAst(n *ast.Namespace) {
-	n.Add(t)
-	t.expr.ExprAst(n)
-}
-
-func (t importtree) TreeAst(n *ast.Namespace) {
-	n.Add(t)
-	t.expr.ExprAst(n)
-}
-
-func (t usingtree) TreeAst(n *ast.Namespace) {
-	n.Add(t)
-	t.expr.ExprAst(n)
-}
-
-func (t typetree) TreeAst(n *ast.Namespace) {
-	n.Add(t)
-	t.expr.ExprAst(n)
-}
-
-func (t templtree) TreeAst(n *ast.Namespace) {
-	n.Add(t)
-	t.expr.ExprAst(n)
-}
-
-func (t vartree) TreeAst(n *ast.Namespace) {
-	n.Add(t)
-}
-
-func (t tagtree) TreeAst(n *ast.Namespace) {
-	n.Add(t)
-}
-
-func (t doctree) TreeAst(n *ast.Namespace) {
-	n.Add(t)
+type selectorexpr struct {
+	baseexpr
+	x   Expr
+	sel token.Token
 }
 
-func (t attrtree) TreeAst(n *ast.Namespace) {
-	n.Add(t)
+type callexpr struct {
+	baseexpr
+	fun  Expr
+	args []Expr
 }
 
-func (e badexpr) ExprAst(*ast.Namespace) {}
-
-func (e pkgexpr) ExprAst(n *ast.Namespace) {
-	name := "" // TODO get the package name from pkgexpr
-	n.SetPackageName(name)
+type parenexpr struct {
+	baseexpr
+	x Expr
 }
 
-func (e importexpr) ExprAst(*ast.Namespace) {}
-
-func (e usingexpr) ExprAst(*ast.Namespace) {}
-
-func (e typeexpr) ExprAst(*ast.Namespace) {}
-
-func (e recordexpr) ExprAst(*ast.Namespace) {}
-
-func (e templexpr) ExprAst(*ast.Namespace) {}
-
-func (e litexpr) ExprAst(*ast.Namespace) {}
-
-func (e litexpr) LitValue(*ast.Namespace) {}
+type litexpr token.Token
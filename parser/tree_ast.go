@@ -0,0 +1,279 @@
+package parser
+
+import (
+	"strings"
+	"temlang/tem/ast"
+	"temlang/tem/token"
+)
+
+func identAst(tok token.Token) *ast.Ident {
+	pos := Position{Start: tok.Start(), End: tok.End()}
+	return &ast.Ident{Name: tok.Text(), Position: pos}
+}
+
+func identsAst(q token.TokenQueue) []*ast.Ident {
+	idents := make([]*ast.Ident, 0, q.Len())
+	for {
+		tok, ok := q.Pop()
+		if !ok {
+			break
+		}
+		idents = append(idents, identAst(tok))
+	}
+	return idents
+}
+
+func directivesAst(q token.TokenQueue) []*ast.Ident {
+	directives := identsAst(q)
+	for _, d := range directives {
+		d.Name = strings.TrimPrefix(d.Name, "#")
+	}
+	return directives
+}
+
+func litAst(tok token.Token) *ast.BasicLit {
+	pos := Position{Start: tok.Start(), End: tok.End()}
+	return &ast.BasicLit{Kind: tok.Kind(), Value: tok.Text(), Position: pos}
+}
+
+func litsAst(q token.TokenQueue) []*ast.BasicLit {
+	lits := make([]*ast.BasicLit, 0, q.Len())
+	for {
+		tok, ok := q.Pop()
+		if !ok {
+			break
+		}
+		lits = append(lits, litAst(tok))
+	}
+	return lits
+}
+
+// annotationAst returns nil if the type of the declaration is inferred
+func annotationAst(dtype token.Token) *ast.Ident {
+	if dtype.Text() == "" {
+		return nil
+	}
+	return identAst(dtype)
+}
+
+func exprAst(e Expr) ast.Expr {
+	if e == nil {
+		return nil
+	}
+	return e.ExprAst()
+}
+
+// treesAst converts the trees of q dropping the ones that are not a T
+func treesAst[T ast.Node](q TreeQueue) []T {
+	ts := make([]T, 0, q.Len())
+	for {
+		tree, ok := q.Pop()
+		if !ok {
+			break
+		}
+		if t, ok := tree.TreeAst().(T); ok {
+			ts = append(ts, t)
+		}
+	}
+	return ts
+}
+
+func (t badtree) TreeAst() ast.Node {
+	return &ast.BadDecl{Position: t.Position}
+}
+
+func (t pkgtree) TreeAst() ast.Node {
+	return &ast.PackageDecl{
+		Names:      identsAst(t.idents),
+		Type:       annotationAst(t.dtype),
+		Directives: directivesAst(t.directives),
+		Value:      exprAst(t.expr),
+		Position:   t.Position,
+	}
+}
+
+func (t importtree) TreeAst() ast.Node {
+	return &ast.ImportDecl{
+		Names:      identsAst(t.idents),
+		Type:       annotationAst(t.dtype),
+		Directives: directivesAst(t.directives),
+		Value:      exprAst(t.expr),
+		Position:   t.Position,
+	}
+}
+
+func (t usingtree) TreeAst() ast.Node {
+	return &ast.UsingDecl{
+		Names:      identsAst(t.idents),
+		Type:       annotationAst(t.dtype),
+		Directives: directivesAst(t.directives),
+		Value:      exprAst(t.expr),
+		Position:   t.Position,
+	}
+}
+
+func (t typetree) TreeAst() ast.Node {
+	return &ast.TypeDecl{
+		Names:      identsAst(t.idents),
+		Type:       annotationAst(t.dtype),
+		Directives: directivesAst(t.directives),
+		Value:      exprAst(t.expr),
+		Position:   t.Position,
+	}
+}
+
+func (t templtree) TreeAst() ast.Node {
+	return &ast.TemplDecl{
+		Names:      identsAst(t.idents),
+		Type:       annotationAst(t.dtype),
+		Directives: directivesAst(t.directives),
+		Value:      exprAst(t.expr),
+		Position:   t.Position,
+	}
+}
+
+func (t vartree) TreeAst() ast.Node {
+	return &ast.Field{
+		Names:    identsAst(t.idents),
+		Type:     identAst(t.dtype),
+		Position: t.Position,
+	}
+}
+
+func (t listvartree) TreeAst() ast.Node {
+	elem := identAst(t.dtype)
+	typ := &ast.ListType{Elem: elem, Position: Position{Start: t.start, End: elem.End}}
+	return &ast.Field{
+		Names:    identsAst(t.idents),
+		Type:     typ,
+		Position: t.Position,
+	}
+}
+
+func (t tagtree) TreeAst() ast.Node {
+	return &ast.Tag{
+		Names:    identsAst(t.idents),
+		Attrs:    treesAst[*ast.Attr](t.attrs),
+		Position: t.Position,
+	}
+}
+
+func (t doctree) TreeAst() ast.Node {
+	return &ast.Doc{
+		Names:    identsAst(t.idents),
+		Text:     litsAst(t.text),
+		Position: t.Position,
+	}
+}
+
+func (t attrtree) TreeAst() ast.Node {
+	return &ast.Attr{
+		Names:    identsAst(t.idents),
+		Value:    exprAst(t.value),
+		Position: t.Position,
+	}
+}
+
+func (t elemtree) TreeAst() ast.Node {
+	return &ast.Element{
+		Name:     identAst(t.name),
+		Attrs:    treesAst[*ast.Attr](t.attrs),
+		Children: treesAst[ast.Markup](t.children),
+		Position: t.Position,
+	}
+}
+
+func (t iftree) TreeAst() ast.Node {
+	n := &ast.IfElement{
+		Cond:     exprAst(t.cond),
+		Children: treesAst[ast.Markup](t.children),
+		Position: t.Position,
+	}
+	if t.els != nil {
+		n.Else = t.els.TreeAst().(*ast.ElseElement)
+	}
+	return n
+}
+
+func (t elsetree) TreeAst() ast.Node {
+	return &ast.ElseElement{Children: treesAst[ast.Markup](t.children), Position: t.Position}
+}
+
+func (t fortree) TreeAst() ast.Node {
+	n := &ast.ForElement{
+		Value:    identAst(t.value),
+		X:        exprAst(t.x),
+		Children: treesAst[ast.Markup](t.children),
+		Position: t.Position,
+	}
+	if t.index.Kind() == token.Ident {
+		n.Index = identAst(t.index)
+	}
+	return n
+}
+
+func (t texttree) TreeAst() ast.Node {
+	return &ast.Text{Value: t.text, Position: t.Position}
+}
+
+func (t interptree) TreeAst() ast.Node {
+	return &ast.Interp{X: exprAst(t.expr), Position: t.Position}
+}
+
+func (e badexpr) ExprAst() ast.Expr {
+	return &ast.BadExpr{Position: e.Pos()}
+}
+
+func (e pkgexpr) ExprAst() ast.Expr {
+	return &ast.PackageExpr{Name: litAst(e.name), Position: e.Pos()}
+}
+
+func (e importexpr) ExprAst() ast.Expr {
+	return &ast.ImportExpr{Path: litAst(e.path), Position: e.Pos()}
+}
+
+func (e usingexpr) ExprAst() ast.Expr {
+	return &ast.UsingExpr{Target: exprAst(e.target), Position: e.Pos()}
+}
+
+func (e typeexpr) ExprAst() ast.Expr {
+	return &ast.TypeExpr{Target: identAst(e.target), Position: e.Pos()}
+}
+
+func (e recordexpr) ExprAst() ast.Expr {
+	return &ast.RecordType{Fields: treesAst[ast.Decl](e.fields), Position: e.Pos()}
+}
+
+func (e templexpr) ExprAst() ast.Expr {
+	return &ast.TemplLit{
+		Params:   treesAst[*ast.Field](e.params),
+		Body:     treesAst[ast.Markup](e.elements),
+		Position: e.Pos(),
+	}
+}
+
+func (e selectorexpr) ExprAst() ast.Expr {
+	return &ast.SelectorExpr{X: exprAst(e.x), Sel: identAst(e.sel), Position: e.Pos()}
+}
+
+func (e callexpr) ExprAst() ast.Expr {
+	args := make([]ast.Expr, 0, len(e.args))
+	for _, a := range e.args {
+		args = append(args, exprAst(a))
+	}
+	return &ast.CallExpr{Fun: exprAst(e.fun), Args: args, Position: e.Pos()}
+}
+
+func (e parenexpr) ExprAst() ast.Expr {
+	return &ast.ParenExpr{X: exprAst(e.x), Position: e.Pos()}
+}
+
+func (e litexpr) ExprAst() ast.Expr {
+	tok := token.Token(e)
+	switch tok.Kind() {
+	case token.String, token.TextBlock:
+		return litAst(tok)
+	default:
+		return identAst(tok)
+	}
+}
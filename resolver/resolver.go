@@ -0,0 +1,286 @@
+package resolver
+
+import (
+	"fmt"
+	"temlang/tem/ast"
+	"temlang/tem/token"
+)
+
+// Resolve declares the names of the namespace in its scope and binds each
+// identifier to the object it denotes. Undeclared and duplicate names are
+// pushed to errors.
+func Resolve(ns *ast.Namespace, errors *token.ErrorQueue) {
+	r := resolver{
+		errors: errors,
+		scope:  ast.NewScope(Universe),
+		templs: ast.NewScope(nil),
+	}
+
+	for _, d := range ns.Decls() {
+		r.declare(d)
+	}
+	ns.SetScope(r.scope, r.templs)
+
+	for _, d := range ns.Decls() {
+		r.resolveDecl(d)
+	}
+}
+
+type resolver struct {
+	errors *token.ErrorQueue
+	scope  *ast.Scope
+	templs *ast.Scope
+}
+
+func (r *resolver) error(offset int, msg string) {
+	r.errors.Push(token.NewError(offset, msg).WithCode(token.CodeResolve))
+}
+
+func (r *resolver) declare(d ast.Decl) {
+	switch d := d.(type) {
+	case *ast.PackageDecl:
+		r.declareNames(r.scope, ast.Pkg, d.Names, d)
+	case *ast.ImportDecl:
+		r.declareNames(r.scope, ast.Imp, d.Names, d)
+	case *ast.UsingDecl:
+		r.declareNames(r.scope, ast.Typ, d.Names, d)
+	case *ast.TypeDecl:
+		r.declareNames(r.scope, ast.Typ, d.Names, d)
+	case *ast.TemplDecl:
+		r.declareNames(r.templs, ast.Tmpl, d.Names, d)
+	case *ast.Field:
+		r.declareNames(r.scope, ast.Var, d.Names, d)
+	}
+}
+
+func (r *resolver) declareNames(scope *ast.Scope, kind ast.ObjKind, names []*ast.Ident, decl ast.Node) {
+	for _, name := range names {
+		obj := ast.NewObj(kind, name.Name, decl)
+		if alt := scope.Insert(obj); alt != nil {
+			err := token.NewError(name.Start, fmt.Sprintf("%s redeclared", name.Name))
+			err = err.WithEnd(name.End).WithCode(token.CodeResolve)
+			r.errors.Push(err.WithRelated(declOffset(alt), "other declaration of "+name.Name))
+			continue
+		}
+		name.Obj = obj
+	}
+}
+
+// declOffset returns the offset of the name of obj in its declaration,
+// the first ident of the declaration denoting obj.
+func declOffset(obj *ast.Object) int {
+	offset, found := obj.Decl.Pos().Start, false
+	ast.Inspect(obj.Decl, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Obj == obj && !found {
+			offset, found = id.Start, true
+		}
+		return !found
+	})
+	return offset
+}
+
+func (r *resolver) resolveDecl(d ast.Decl) {
+	switch d := d.(type) {
+	case *ast.UsingDecl:
+		r.resolveExpr(d.Value)
+	case *ast.TypeDecl:
+		r.resolveExpr(d.Value)
+	case *ast.TemplDecl:
+		r.resolveTempl(d)
+	case *ast.Field:
+		r.resolveType(d.Type)
+	case *ast.Doc:
+		r.bindNames(r.scope, d.Names)
+	case *ast.Tag:
+		r.bindNames(r.scope, d.Names)
+	}
+}
+
+// bindNames binds the names of a doc or tag to the declarations they
+// describe. Names without a declaration are left unresolved.
+func (r *resolver) bindNames(scope *ast.Scope, names []*ast.Ident) {
+	for _, name := range names {
+		obj := scope.Lookup(name.Name)
+		if obj == nil && scope == r.scope {
+			obj = r.templs.Lookup(name.Name)
+		}
+		name.Obj = obj
+	}
+}
+
+func (r *resolver) resolveExpr(e ast.Expr) {
+	switch e := e.(type) {
+	case *ast.TypeExpr:
+		r.resolveType(e.Target)
+	case *ast.UsingExpr:
+		r.resolvePackage(e.Target)
+	case *ast.RecordType:
+		r.resolveRecord(e)
+	}
+}
+
+func (r *resolver) resolveRecord(e *ast.RecordType) {
+	fields := ast.NewScope(nil)
+	for _, d := range e.Fields {
+		field, ok := d.(*ast.Field)
+		if !ok {
+			continue
+		}
+		r.declareNames(fields, ast.Var, field.Names, field)
+		if ident, ok := field.Type.(*ast.Ident); ok && ident.Name == "type" {
+			r.error(ident.Start, "type can only be inferred for a templ parameter")
+			continue
+		}
+		r.resolveType(field.Type)
+	}
+
+	for _, d := range e.Fields {
+		switch d := d.(type) {
+		case *ast.Doc:
+			r.bindNames(fields, d.Names)
+		case *ast.Tag:
+			r.bindNames(fields, d.Names)
+		}
+	}
+}
+
+func (r *resolver) resolveType(e ast.Expr) {
+	if list, ok := e.(*ast.ListType); ok {
+		r.resolveType(list.Elem)
+		return
+	}
+	ident, ok := e.(*ast.Ident)
+	if !ok {
+		return
+	}
+	ident.Obj = r.lookup(ident.Name, ident.Start)
+	r.checkType(ident.Obj, ident.Name, ident.Start)
+}
+
+func (r *resolver) checkType(obj *ast.Object, name string, offset int) {
+	if obj == nil {
+		return
+	}
+	if obj.Kind != ast.Typ && obj.Kind != ast.Builtin {
+		r.error(offset, fmt.Sprintf("%s is not a type", name))
+	}
+}
+
+func (r *resolver) resolvePackage(e ast.Expr) {
+	if sel, ok := e.(*ast.SelectorExpr); ok {
+		// the selector names a namespace of the package, the namespaces
+		// are linked by the loader
+		r.resolvePackage(sel.X)
+		return
+	}
+	ident, ok := e.(*ast.Ident)
+	if !ok {
+		return
+	}
+	ident.Obj = r.lookup(ident.Name, ident.Start)
+	if obj := ident.Obj; obj != nil && obj.Kind != ast.Imp && obj.Kind != ast.Pkg {
+		r.error(ident.Start, fmt.Sprintf("%s is not a package", ident.Name))
+	}
+}
+
+func (r *resolver) lookup(name string, offset int) *ast.Object {
+	obj := r.scope.LookupParent(name)
+	if obj == nil {
+		r.error(offset, fmt.Sprintf("undeclared name: %s", name))
+	}
+	return obj
+}
+
+func (r *resolver) resolveTempl(d *ast.TemplDecl) {
+	lit, ok := d.Value.(*ast.TemplLit)
+	if !ok {
+		return
+	}
+
+	scope := ast.NewScope(r.scope)
+	for _, param := range lit.Params {
+		r.declareNames(scope, ast.Var, param.Names, param)
+
+		// the type of the param is inferred from the name of the templ
+		if ident, ok := param.Type.(*ast.Ident); ok && ident.Name == "type" {
+			if len(d.Names) > 0 {
+				name := d.Names[0].Name
+				ident.Obj = r.lookup(name, ident.Start)
+				r.checkType(ident.Obj, name, ident.Start)
+			}
+			continue
+		}
+		r.resolveType(param.Type)
+	}
+
+	outer := r.scope
+	r.scope = scope
+	for _, m := range lit.Body {
+		r.resolveMarkup(m)
+	}
+	r.scope = outer
+}
+
+func (r *resolver) resolveMarkup(m ast.Markup) {
+	switch m := m.(type) {
+	case *ast.Element:
+		for _, attr := range m.Attrs {
+			r.resolveValue(attr.Value)
+		}
+		for _, child := range m.Children {
+			r.resolveMarkup(child)
+		}
+	case *ast.Interp:
+		r.resolveValue(m.X)
+	case *ast.IfElement:
+		r.resolveValue(m.Cond)
+		for _, child := range m.Children {
+			r.resolveMarkup(child)
+		}
+		if m.Else != nil {
+			for _, child := range m.Else.Children {
+				r.resolveMarkup(child)
+			}
+		}
+	case *ast.ForElement:
+		r.resolveFor(m)
+	}
+}
+
+// resolveFor declares the value and the index of a for element in the
+// scope of its children.
+func (r *resolver) resolveFor(m *ast.ForElement) {
+	r.resolveValue(m.X)
+
+	scope := ast.NewScope(r.scope)
+	names := []*ast.Ident{m.Value}
+	if m.Index != nil {
+		names = []*ast.Ident{m.Index, m.Value}
+	}
+	r.declareNames(scope, ast.Var, names, m)
+
+	outer := r.scope
+	r.scope = scope
+	for _, child := range m.Children {
+		r.resolveMarkup(child)
+	}
+	r.scope = outer
+}
+
+// resolveValue resolves the operands of an expression in a templ. The
+// selectors are resolved by the type checker.
+func (r *resolver) resolveValue(e ast.Expr) {
+	switch e := e.(type) {
+	case *ast.Ident:
+		e.Obj = r.lookup(e.Name, e.Start)
+	case *ast.SelectorExpr:
+		r.resolveValue(e.X)
+	case *ast.ParenExpr:
+		r.resolveValue(e.X)
+	case *ast.CallExpr:
+		r.resolveValue(e.Fun)
+		for _, arg := range e.Args {
+			r.resolveValue(arg)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package resolver_test
+
+import (
+	"fmt"
+	"temlang/tem/ast"
+	"temlang/tem/parser"
+	"temlang/tem/resolver"
+	"temlang/tem/token"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func resolve(t *testing.T, src string) (*ast.Namespace, *token.ErrorQueue) {
+	t.Helper()
+	file, errs := parser.ParseFile("test.tem", []byte(src))
+	if errs.Len() != 0 {
+		t.Fatalf("ParseFile(%s) failed unexpectedly", src)
+	}
+	resolver.Resolve(file, errs)
+	return file, errs
+}
+
+var valids = []string{
+	`p :: package("m");   t :: type(String)`,
+	`p :: package("m");   t :: record{ a: String; b: Int; c: Bool; d: Float }`,
+	`p :: package("m");   t :: type(r); r :: record{ a: String }`,
+	`p :: package("m");   t :: record{ a: String; a: "doc"; a: { k = "v" } }`,
+	`p :: package("m");   i :: import("i"); a, b :: using(i); t :: type(a)`,
+	`p :: package("m");   a :: using(p)`,
+	`p :: package("m");   a :: using(p.ns)`,
+	`p :: package("m");   t : String`,
+	`p :: package("m");   t :: record{}; t :: templ(t: t){}`,
+	`p :: package("m");   t :: record{}; t :: templ(t: type){}`,
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <p (v.a)/> }`,
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <a href=(v.a)/> }`,
+	`p :: package("m");   t : "doc"; t :: record{}`,
+}
+
+func TestResolveValids(t *testing.T) {
+	for i, src := range valids {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			_, errs := resolve(t, src)
+			for !errs.Empty() {
+				err, _ := errs.Pop()
+				t.Error(err)
+			}
+		})
+	}
+}
+
+var invalids = []string{
+	// undeclared
+	`p :: package("m");   t :: type(Person)`,
+	`p :: package("m");   t :: record{ a: Person }`,
+	`p :: package("m");   t : Person`,
+	`p :: package("m");   a :: using(i)`,
+	`p :: package("m");   a :: using(i.ns)`,
+	`p :: package("m");   t :: templ(v: Person){}`,
+	`p :: package("m");   t :: templ(v: type){}`,
+	`p :: package("m");   t :: record{}; t :: templ(v: t){ <p (x)/> }`,
+	`p :: package("m");   t :: record{}; t :: templ(v: t){ <p (x.a)/> }`,
+	`p :: package("m");   t :: record{}; t :: templ(v: t){ <a href=(x)/> }`,
+	// duplicate
+	`p :: package("m");   t :: type(String); t :: type(Int)`,
+	`p :: package("m");   t :: type(String); t :: record{}`,
+	`p :: package("m");   t :: record{}; t :: templ(v: t){}; t :: templ(v: t){}`,
+	`p :: package("m");   t :: record{ a: String; a: Int }`,
+	`p :: package("m");   t :: record{}; t :: templ(v: t, v: t){}`,
+	// kind
+	`p :: package("m");   t :: type(p)`,
+	`p :: package("m");   a :: using(p); b :: using(a)`,
+	`p :: package("m");   t :: record{ a: type }`,
+}
+
+func TestResolveInvalids(t *testing.T) {
+	for i, src := range invalids {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			_, errs := resolve(t, src)
+			if errs.Len() == 0 {
+				t.Errorf("Resolve(%s) succeeded unexpectedly", src)
+			}
+		})
+	}
+}
+
+func TestResolveBindings(t *testing.T) {
+	src := `
+		p :: package("m")
+		Person :: record{ name: String }
+		User :: type(Person)
+		User :: templ(u: type){ <p (u.name)/> }
+	`
+	file, errs := resolve(t, src)
+	if errs.Len() != 0 {
+		t.Fatal("Resolve failed unexpectedly")
+	}
+
+	decls := file.Decls()
+	person := decls[1].(*ast.TypeDecl)
+	user := decls[2].(*ast.TypeDecl)
+	templ := decls[3].(*ast.TemplDecl)
+
+	if obj := file.Scope().Lookup("Person"); obj == nil || obj.Decl != person {
+		t.Errorf("expected Person to be declared by %v got %v", person, obj)
+	}
+	if obj := file.Templs().Lookup("User"); obj == nil || obj.Decl != templ {
+		t.Errorf("expected templ User to be declared by %v got %v", templ, obj)
+	}
+
+	target := user.Value.(*ast.TypeExpr).Target
+	if target.Obj == nil || target.Obj.Decl != person {
+		t.Errorf("expected type(Person) to be bound to %v got %v", person, target.Obj)
+	}
+
+	field := person.Value.(*ast.RecordType).Fields[0].(*ast.Field)
+	if obj := field.Type.(*ast.Ident).Obj; obj == nil || obj.Kind != ast.Builtin {
+		t.Errorf("expected String to be a builtin got %v", obj)
+	}
+
+	lit := templ.Value.(*ast.TemplLit)
+	param := lit.Params[0]
+	if obj := param.Type.(*ast.Ident).Obj; obj == nil || obj.Decl != user {
+		t.Errorf("expected the inferred param type to be bound to %v got %v", user, obj)
+	}
+
+	interp := lit.Body[0].(*ast.Element).Children[0].(*ast.Interp)
+	x := interp.X.(*ast.SelectorExpr).X.(*ast.Ident)
+	if x.Obj == nil || x.Obj.Decl != param {
+		t.Errorf("expected u to be bound to %v got %v", param, x.Obj)
+	}
+}
+
+func TestResolveRedeclared(t *testing.T) {
+	src := `p :: package("m"); a, b :: type(String); b :: type(Int)`
+	file, errs := resolve(t, src)
+	err, ok := errs.Pop()
+	if !ok || errs.Len() != 0 {
+		t.Fatalf("expected a single error got %d", errs.Len()+1)
+	}
+	if code := err.Code(); code != token.CodeResolve {
+		t.Errorf("expected code %s got %s", token.CodeResolve, code)
+	}
+
+	d := file.Diagnostic(err)
+	if pos := (token.Pos{Line: 1, Col: 42}); d.Start != pos || d.End.Col != 43 {
+		t.Errorf("expected b redeclared at %v got %v - %v", pos, d.Start, d.End)
+	}
+	related := []token.Related{{
+		Location: token.Location{File: "test.tem", Start: token.Pos{Line: 1, Col: 23}, End: token.Pos{Line: 1, Col: 23}},
+		Message:  "other declaration of b",
+	}}
+	if diff := cmp.Diff(related, d.Related); diff != "" {
+		t.Error(diff)
+	}
+}
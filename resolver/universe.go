@@ -0,0 +1,32 @@
+package resolver
+
+import "temlang/tem/ast"
+
+var builtins = []string{
+	"Bool",
+	"Float",
+	"HTML",
+	"Int",
+	"String",
+}
+
+var helpers = []string{
+	"lower",
+	"trim",
+	"upper",
+}
+
+// Universe is the outermost scope of every namespace. It contains the
+// builtin types and helpers.
+var Universe = universe()
+
+func universe() *ast.Scope {
+	s := ast.NewScope(nil)
+	for _, name := range builtins {
+		s.Insert(ast.NewObj(ast.Builtin, name, nil))
+	}
+	for _, name := range helpers {
+		s.Insert(ast.NewObj(ast.Helper, name, nil))
+	}
+	return s
+}
@@ -0,0 +1,179 @@
+package token
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Severity is the importance of a diagnostic.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	// SeverityNote is the severity of the related locations of a
+	// diagnostic
+	SeverityNote
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityNote:
+		return "note"
+	default:
+		return fmt.Sprintf("Severity(%d)", s)
+	}
+}
+
+// Code identifies the stage reporting an error.
+type Code string
+
+const (
+	// CodeSyntax is an error of the tokenizer or the parser
+	CodeSyntax Code = "syntax"
+	// CodeAnnotate is a doc or a tag without a declaration
+	CodeAnnotate Code = "annotate"
+	// CodeResolve is an error of the resolution of the names
+	CodeResolve Code = "resolve"
+	// CodeType is an error of the type checker
+	CodeType Code = "type"
+	// CodeGen is an error of a code generator
+	CodeGen Code = "gen"
+	// CodeLoad is an error of the loading of the packages
+	CodeLoad Code = "load"
+)
+
+// Related is a location related to a diagnostic, like the other
+// declaration of a redeclared name.
+type Related struct {
+	Location
+	Message string
+}
+
+// Diagnostic is an error located by line and column in a file.
+type Diagnostic struct {
+	Location
+	Severity Severity
+	Code     Code
+	Message  string
+	Related  []Related
+}
+
+// String returns the diagnostic on a single line:
+//
+//	file:line:col: severity[code]: message
+func (d Diagnostic) String() string {
+	label := d.Severity.String()
+	if d.Code != "" {
+		label += "[" + string(d.Code) + "]"
+	}
+	return header(d.Location, label, d.Message)
+}
+
+// Render writes the diagnostic followed by its line of src with the source
+// in error underlined, then each related location the same way. src is the
+// content of the file of the diagnostic.
+//
+//	user.tem:3:18: error[resolve]: undeclared name: Person
+//	 3 | User :: templ(u: Person){
+//	   |                  ^^^^^^
+func (d Diagnostic) Render(w io.Writer, src []byte) error {
+	var b strings.Builder
+	b.WriteString(d.String() + "\n")
+	snippet(&b, src, d.Location)
+	for _, r := range d.Related {
+		b.WriteString(header(r.Location, SeverityNote.String(), r.Message) + "\n")
+		if r.File == d.File {
+			snippet(&b, src, r.Location)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func header(l Location, label, msg string) string {
+	pos := fmt.Sprintf("%d:%d", l.Start.Line, l.Start.Col)
+	if l.File != "" {
+		pos = l.File + ":" + pos
+	}
+	return pos + ": " + label + ": " + msg
+}
+
+// snippet writes the line of src holding the start of l and a line of
+// carets under the part of l on that line. A location with no extent
+// underlines the word at its start.
+func snippet(b *strings.Builder, src []byte, l Location) {
+	line, ok := sourceLine(src, l.Start.Line)
+	if !ok {
+		return
+	}
+	start := min(max(l.Start.Col-1, 0), len(line))
+	var end int
+	switch {
+	case l.End.Line > l.Start.Line:
+		end = len(line)
+	case l.End.Line == l.Start.Line && l.End.Col > l.Start.Col:
+		end = min(l.End.Col-1, len(line))
+	default:
+		end = start + wordLen(line[start:])
+	}
+
+	// the chars before the carets are blanked keeping the tabs so the
+	// carets line up with the source whatever the tab width
+	var pad strings.Builder
+	for _, r := range line[:start] {
+		if r == '\t' {
+			pad.WriteRune('\t')
+			continue
+		}
+		pad.WriteRune(' ')
+	}
+	carets := max(utf8.RuneCountInString(line[start:end]), 1)
+
+	num := strconv.Itoa(l.Start.Line)
+	fmt.Fprintf(b, " %s | %s\n", num, line)
+	fmt.Fprintf(b, " %s | %s%s\n", strings.Repeat(" ", len(num)), pad.String(), strings.Repeat("^", carets))
+}
+
+// sourceLine returns the 1 based line n of src without its newline.
+func sourceLine(src []byte, n int) (string, bool) {
+	for i := 1; i < n; i++ {
+		j := bytes.IndexByte(src, '\n')
+		if j < 0 {
+			return "", false
+		}
+		src = src[j+1:]
+	}
+	if n < 1 {
+		return "", false
+	}
+	if j := bytes.IndexByte(src, '\n'); j >= 0 {
+		src = src[:j]
+	}
+	return strings.TrimSuffix(string(src), "\r"), true
+}
+
+// wordLen returns the length in bytes of the identifier s starts with or
+// of its first char if it does not start with an identifier.
+func wordLen(s string) int {
+	n := 0
+	for _, r := range s {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			break
+		}
+		n += utf8.RuneLen(r)
+	}
+	if n == 0 && s != "" {
+		_, n = utf8.DecodeRuneInString(s)
+	}
+	return n
+}
@@ -0,0 +1,131 @@
+package token_test
+
+import (
+	"strings"
+	"temlang/tem/token"
+	"testing"
+)
+
+func location(line, col, endLine, endCol int) token.Location {
+	return token.Location{
+		File:  "a.tem",
+		Start: token.Pos{Line: line, Col: col},
+		End:   token.Pos{Line: endLine, Col: endCol},
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	d := token.Diagnostic{Location: location(2, 3, 2, 3), Code: token.CodeType, Message: "msg"}
+	if got, want := d.String(), "a.tem:2:3: error[type]: msg"; got != want {
+		t.Errorf("expected %q got %q", want, got)
+	}
+	d = token.Diagnostic{Location: location(1, 1, 1, 1), Severity: token.SeverityWarning, Message: "msg"}
+	if got, want := d.String(), "a.tem:1:1: warning: msg"; got != want {
+		t.Errorf("expected %q got %q", want, got)
+	}
+}
+
+func TestSeverityString(t *testing.T) {
+	if got, want := token.SeverityNote.String(), "note"; got != want {
+		t.Errorf("expected %q got %q", want, got)
+	}
+	if got, want := token.Severity(7).String(), "Severity(7)"; got != want {
+		t.Errorf("expected %q got %q", want, got)
+	}
+}
+
+func TestDiagnosticRender(t *testing.T) {
+	src := "p :: package(\"m\")\nUser :: record{ name: Person }\n\ta :: type(été)\n"
+	testcases := []struct {
+		name     string
+		d        token.Diagnostic
+		expected []string
+	}{
+		{
+			name: "word",
+			d:    token.Diagnostic{Location: location(2, 23, 2, 23), Code: token.CodeResolve, Message: "undeclared name: Person"},
+			expected: []string{
+				"a.tem:2:23: error[resolve]: undeclared name: Person",
+				" 2 | User :: record{ name: Person }",
+				"   |                       ^^^^^^",
+			},
+		},
+		{
+			name: "range",
+			d:    token.Diagnostic{Location: location(2, 9, 2, 31), Message: "msg"},
+			expected: []string{
+				"a.tem:2:9: error: msg",
+				" 2 | User :: record{ name: Person }",
+				"   |         ^^^^^^^^^^^^^^^^^^^^^^",
+			},
+		},
+		{
+			name: "lines",
+			d:    token.Diagnostic{Location: location(1, 6, 3, 1), Message: "msg"},
+			expected: []string{
+				"a.tem:1:6: error: msg",
+				" 1 | p :: package(\"m\")",
+				"   |      ^^^^^^^^^^^^",
+			},
+		},
+		{
+			name: "symbol",
+			d:    token.Diagnostic{Location: location(1, 13, 1, 13), Message: "msg"},
+			expected: []string{
+				"a.tem:1:13: error: msg",
+				" 1 | p :: package(\"m\")",
+				"   |             ^",
+			},
+		},
+		{
+			name: "tab and unicode",
+			d:    token.Diagnostic{Location: location(3, 12, 3, 12), Message: "msg"},
+			expected: []string{
+				"a.tem:3:12: error: msg",
+				" 3 | \ta :: type(été)",
+				"   | \t          ^^^",
+			},
+		},
+		{
+			name: "end of file",
+			d:    token.Diagnostic{Location: location(4, 1, 4, 1), Message: "msg"},
+			expected: []string{
+				"a.tem:4:1: error: msg",
+				" 4 | ",
+				"   | ^",
+			},
+		},
+		{
+			name: "related",
+			d: token.Diagnostic{
+				Location: location(3, 2, 3, 3),
+				Message:  "a redeclared",
+				Related: []token.Related{
+					{Location: location(1, 1, 1, 1), Message: "other declaration of a"},
+					{Location: token.Location{File: "b.tem", Start: token.Pos{Line: 1, Col: 1}}, Message: "elsewhere"},
+				},
+			},
+			expected: []string{
+				"a.tem:3:2: error: a redeclared",
+				" 3 | \ta :: type(été)",
+				"   | \t^",
+				"a.tem:1:1: note: other declaration of a",
+				" 1 | p :: package(\"m\")",
+				"   | ^",
+				"b.tem:1:1: note: elsewhere",
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := tc.d.Render(&b, []byte(src)); err != nil {
+				t.Fatal(err)
+			}
+			expected := strings.Join(tc.expected, "\n") + "\n"
+			if got := b.String(); got != expected {
+				t.Errorf("expected\n%s\ngot\n%s", expected, got)
+			}
+		})
+	}
+}
@@ -6,24 +6,60 @@ import (
 )
 
 func NewError(offset int, msg string) Error {
-	return Error{offset: offset, msg: &msg}
+	return Error{offset: offset, end: offset, msg: &msg}
 }
 
 type ErrorQueue = queue.Queue[Error]
 
 type Error struct {
-	offset int
-	msg    *string
+	offset  int
+	end     int
+	code    Code
+	msg     *string
+	related []Error
 }
 
 func (e Error) Offset() int {
 	return e.offset
 }
 
+// End returns the end offset of the source in error. It is the offset of
+// the error unless set with WithEnd.
+func (e Error) End() int {
+	return e.end
+}
+
+func (e Error) Code() Code {
+	return e.code
+}
+
 func (e Error) Message() string {
 	return *e.msg
 }
 
+// Related returns the other locations of the source related to the error.
+func (e Error) Related() []Error {
+	return e.related
+}
+
+// WithEnd returns e with end as the end offset of the source in error.
+func (e Error) WithEnd(end int) Error {
+	e.end = end
+	return e
+}
+
+func (e Error) WithCode(code Code) Error {
+	e.code = code
+	return e
+}
+
+// WithRelated returns e with a related location at offset described by
+// msg.
+func (e Error) WithRelated(offset int, msg string) Error {
+	e.related = append(e.related[:len(e.related):len(e.related)], NewError(offset, msg))
+	return e
+}
+
 func (e Error) String() string {
 	return fmt.Sprintf("%s at %d", *e.msg, e.offset)
 }
@@ -0,0 +1,73 @@
+package token
+
+import "sort"
+
+// File is the name and the line offsets of a parsed file.
+type File struct {
+	name string
+	// lines starts with the start of the file followed by the offset of
+	// each newline
+	lines []int
+}
+
+func NewFile(name string, lines []int) *File {
+	return &File{name: name, lines: lines}
+}
+
+func (f *File) Name() string {
+	return f.name
+}
+
+func (f *File) Lines() []int {
+	return f.lines
+}
+
+// LineCol returns the 1 based line and column of offset.
+func (f *File) LineCol(offset int) Pos {
+	if len(f.lines) == 0 {
+		return Pos{Line: 1, Col: offset + 1}
+	}
+	newlines := f.lines[1:]
+	i := sort.SearchInts(newlines, offset)
+	if i == 0 {
+		return Pos{Line: 1, Col: offset + 1}
+	}
+	start := newlines[i-1] + 1
+	return Pos{Line: i + 1, Col: offset - start + 1}
+}
+
+// FileSet is a set of files sorted by name. It is not modified once
+// created so it can be shared by goroutines.
+type FileSet struct {
+	files []*File
+}
+
+func NewFileSet(files ...*File) *FileSet {
+	sorted := make([]*File, len(files))
+	copy(sorted, files)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+	return &FileSet{files: sorted}
+}
+
+// File returns the file named name or nil.
+func (s *FileSet) File(name string) *File {
+	i := sort.Search(len(s.files), func(i int) bool { return s.files[i].name >= name })
+	if i < len(s.files) && s.files[i].name == name {
+		return s.files[i]
+	}
+	return nil
+}
+
+// Files returns the files sorted by name, the slice must not be modified.
+func (s *FileSet) Files() []*File {
+	return s.files
+}
+
+// Position returns the location of offset in the file named name.
+func (s *FileSet) Position(name string, offset int) Pos {
+	f := s.File(name)
+	if f == nil {
+		return Pos{}
+	}
+	return f.LineCol(offset)
+}
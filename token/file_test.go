@@ -0,0 +1,36 @@
+package token_test
+
+import (
+	"temlang/tem/token"
+	"testing"
+)
+
+func TestFileSet(t *testing.T) {
+	// "ab\ncd\n\nef"
+	b := token.NewFile("b.tem", []int{0, 2, 5, 6})
+	a := token.NewFile("a.tem", nil)
+	fset := token.NewFileSet(b, a)
+
+	if files := fset.Files(); len(files) != 2 || files[0] != a || files[1] != b {
+		t.Errorf("expected the files sorted by name got %v", files)
+	}
+	if fset.File("b.tem") != b || fset.File("c.tem") != nil {
+		t.Error("File found the wrong file")
+	}
+
+	testcases := map[int]token.Pos{
+		0: {Line: 1, Col: 1},
+		2: {Line: 1, Col: 3},
+		3: {Line: 2, Col: 1},
+		6: {Line: 3, Col: 1},
+		8: {Line: 4, Col: 2},
+	}
+	for offset, expected := range testcases {
+		if got := fset.Position("b.tem", offset); got != expected {
+			t.Errorf("Position(%d) = %v, expected %v", offset, got, expected)
+		}
+	}
+	if got := fset.Position("a.tem", 4); got != (token.Pos{Line: 1, Col: 5}) {
+		t.Errorf("Position without lines = %v", got)
+	}
+}
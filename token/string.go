@@ -30,6 +30,12 @@ func (k Kind) String() string {
 		return "]"
 	case BraceOpen:
 		return "{"
+	case InterpOpen:
+		return "("
+	case InterpClose:
+		return ")"
+	case SelfClose:
+		return "/>"
 	case BraceClose:
 		return "}"
 	case ParenOpen:
@@ -54,6 +60,10 @@ func (k Kind) String() string {
 		return "record"
 	case TextBlock:
 		return "text_block"
+	case ElementOpen:
+		return "<"
+	case Text:
+		return "text"
 	case Import:
 		return "import"
 	case Using:
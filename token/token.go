@@ -62,9 +62,15 @@ const (
 	Comma
 	Dot
 	Eq
+	// InterpClose close paren ending an interpolation in markup
+	InterpClose
+	// InterpOpen open paren starting an interpolation in markup
+	InterpOpen
 	ParenClose
 	ParenOpen
 	Semicolon
+	// SelfClose element terminator />
+	SelfClose
 	Space
 	SymbolEnd
 
@@ -78,9 +84,13 @@ const (
 	KeywordEnd
 
 	LiteralBegin
+	// ElementOpen element open in markup, e.g <div
+	ElementOpen
 	Ident
 	String
 	TextBlock
+	// Text free text in markup
+	Text
 	Directive
 	LiteralEnd
 
@@ -0,0 +1,96 @@
+package token
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrSyntax is returned by Unquote for a text that is not a string
+// literal.
+var ErrSyntax = errors.New("invalid string literal")
+
+// Unquote returns the value of a string token: its text without the quotes
+// and with the escape sequences decoded.
+func (t Token) Unquote() (string, error) {
+	return Unquote(t.text)
+}
+
+// Unquote returns the value of the string literal s. s is either quoted
+// with double quotes and may contain the escape sequences \", \\, \n, \r,
+// \t and \uXXXX, or quoted with backticks and taken as is.
+func Unquote(s string) (string, error) {
+	n := len(s)
+	if n < 2 || s[0] != s[n-1] {
+		return "", ErrSyntax
+	}
+	quote, s := s[0], s[1:n-1]
+	switch quote {
+	case '`':
+		if strings.IndexByte(s, '`') >= 0 {
+			return "", ErrSyntax
+		}
+		return s, nil
+	case '"':
+	default:
+		return "", ErrSyntax
+	}
+	if !strings.ContainsAny(s, "\\\"\n") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch c {
+		case '"', '\n':
+			return "", ErrSyntax
+		case '\\':
+		default:
+			b.WriteByte(c)
+			i += 1
+			continue
+		}
+		if i+1 >= len(s) {
+			return "", ErrSyntax
+		}
+		switch e := s[i+1]; e {
+		case '"', '\\':
+			b.WriteByte(e)
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			if i+6 > len(s) {
+				return "", ErrSyntax
+			}
+			v, err := strconv.ParseUint(s[i+2:i+6], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(v)) {
+				return "", ErrSyntax
+			}
+			b.WriteRune(rune(v))
+			i += 4
+		default:
+			return "", ErrSyntax
+		}
+		i += 2
+	}
+	return b.String(), nil
+}
+
+// TextBlockText returns the text of the text block s: its text without the
+// -- or --- marker and the space following it. The dashes after the marker
+// are text.
+func TextBlockText(s string) string {
+	if strings.HasPrefix(s, "---") {
+		s = s[3:]
+	} else {
+		s = strings.TrimPrefix(s, "--")
+	}
+	return strings.TrimPrefix(s, " ")
+}
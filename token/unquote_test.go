@@ -0,0 +1,76 @@
+package token_test
+
+import (
+	"temlang/tem/token"
+	"testing"
+)
+
+func TestUnquote(t *testing.T) {
+	testcases := map[string]string{
+		`""`:          "",
+		`"foo"`:       "foo",
+		`"a\"b"`:      `a"b`,
+		`"a\\b"`:      `a\b`,
+		`"a\nb\tc\r"`: "a\nb\tc\r",
+		`"café"`:      "café",
+		`"été"`:       "été",
+		"`a\\nb`":     `a\nb`,
+		"`a\nb`":      "a\nb",
+		"`\"`":        `"`,
+	}
+	for src, expected := range testcases {
+		t.Run(src, func(t *testing.T) {
+			got, err := token.NewWithText(token.String, src, 0, len(src)).Unquote()
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if got != expected {
+				t.Errorf("expected %q got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestUnquoteError(t *testing.T) {
+	testcases := []string{
+		``,
+		`"`,
+		`foo`,
+		`"foo`,
+		`"foo'`,
+		`"a"b"`,
+		"\"a\nb\"",
+		`"\"`,
+		`"\q"`,
+		`"\u00"`,
+		`"\uzzzz"`,
+		`"\ud800"`,
+		"`a`b`",
+	}
+	for _, src := range testcases {
+		t.Run(src, func(t *testing.T) {
+			if got, err := token.Unquote(src); err != token.ErrSyntax {
+				t.Errorf("expected %v got %q, %v", token.ErrSyntax, got, err)
+			}
+		})
+	}
+}
+
+func TestTextBlockText(t *testing.T) {
+	testcases := map[string]string{
+		"--":          "",
+		"---":         "",
+		"-- foo":      "foo",
+		"--- foo":     "foo",
+		"--foo":       "foo",
+		"--- -":       "-",
+		"----":        "-",
+		"--- - item":  "- item",
+		"---  indent": " indent",
+	}
+	for src, expected := range testcases {
+		if got := token.TextBlockText(src); got != expected {
+			t.Errorf("TextBlockText(%q) = %q, expected %q", src, got, expected)
+		}
+	}
+}
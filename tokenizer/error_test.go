@@ -31,8 +31,15 @@ func HelperRunTestCasesError(t *testing.T, testcases TestCaseError) {
 
 func TestNextStringError(t *testing.T) {
 	textcases := TestCaseError{
-		`"`:    {errCount: 1, offsetAfter: 1},
-		`"abc`: {errCount: 1, offsetAfter: 4},
+		`"`:        {errCount: 1, offsetAfter: 1},
+		`"abc`:     {errCount: 1, offsetAfter: 4},
+		`"\"`:      {errCount: 1, offsetAfter: 3},
+		`"\q"`:     {errCount: 1, offsetAfter: 4},
+		`"\u00"`:   {errCount: 1, offsetAfter: 6},
+		`"\uzzzz"`: {errCount: 1, offsetAfter: 8},
+		`"\ud800"`: {errCount: 1, offsetAfter: 8},
+		"`abc":     {errCount: 1, offsetAfter: 4},
+		"`a\nb":    {errCount: 1, offsetAfter: 4},
 	}
 	HelperRunTestCasesError(t, textcases)
 }
@@ -43,3 +50,14 @@ func TestNextCommentError(t *testing.T) {
 	}
 	HelperRunTestCasesError(t, testcases)
 }
+
+func TestNextEncodingError(t *testing.T) {
+	testcases := TestCaseError{
+		"\xff":         {errCount: 1, offsetAfter: 1},
+		"\xe9t\xe9":    {errCount: 1, offsetAfter: 2},
+		"a\uFEFF":      {errCount: 1, offsetAfter: 4},
+		"\uFEFF\uFEFF": {errCount: 1, offsetAfter: 6},
+		"// \xff":      {errCount: 1, offsetAfter: 4},
+	}
+	HelperRunTestCasesError(t, testcases)
+}
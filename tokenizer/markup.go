@@ -0,0 +1,192 @@
+package tokenizer
+
+import (
+	"temlang/tem/token"
+	"unicode/utf8"
+)
+
+type mode int
+
+const (
+	modeCode mode = iota
+	// modeMarkup element content of a template
+	modeMarkup
+	// modeTag the attributes following an element open
+	modeTag
+	// modeInterp the expression inside an interpolation
+	modeInterp
+)
+
+type markupState struct {
+	mode mode
+	// last kind of token returned; EOL and comments are not recorded
+	last token.Kind
+	// depth of open elements
+	depth int
+	// depth of parens nested inside an interpolation
+	parens int
+	// mode to return to at the end of an interpolation
+	ret mode
+}
+
+func (t *Tokenizer) peek() rune {
+	if t.rdOffset >= len(t.src) {
+		return eof
+	}
+	r, _ := utf8.DecodeRune(t.src[t.rdOffset:])
+	return r
+}
+
+// updateMarkup enters markup after the brace that follows the param list
+// of a templ literal and tracks the end of an interpolation.
+func (t *Tokenizer) updateMarkup(kind token.Kind) token.Kind {
+	switch t.markup.mode {
+	case modeCode:
+		t.semicolonFunc(t, kind)
+		if kind == token.BraceOpen && t.markup.last == token.ParenClose {
+			t.markup.mode = modeMarkup
+			t.markup.depth = 0
+			t.insertSemicolon = false
+		}
+	case modeInterp:
+		switch kind {
+		case token.ParenOpen:
+			t.markup.parens += 1
+		case token.ParenClose:
+			if t.markup.parens == 0 {
+				kind = token.InterpClose
+				t.markup.mode = t.markup.ret
+				break
+			}
+			t.markup.parens -= 1
+		}
+	}
+	return kind
+}
+
+func (t *Tokenizer) nextMarkup() token.Token {
+	var kind token.Kind
+
+	if t.markup.mode == modeTag {
+		t.skipMarkupSpace()
+		offset := t.offset
+		if kind, ok := t.attr(); ok {
+			return t.markupToken(kind, offset)
+		}
+		t.markup.mode = modeMarkup
+	}
+
+	offset := t.offset
+
+	switch ch := t.ch; {
+	case ch == eof:
+		return token.New(token.EOF, offset, t.offset)
+	case ch == '}':
+		t.advance()
+		kind = token.BraceClose
+		t.markup.mode = modeCode
+		t.semicolonFunc(t, kind)
+	case ch == '<' && isLetter(t.peek()):
+		t.advance()
+		t.ident()
+		kind = token.ElementOpen
+		t.markup.depth += 1
+		t.markup.mode = modeTag
+	case ch == '/' && t.peek() == '>':
+		t.advance()
+		t.advance()
+		kind = token.SelfClose
+		if t.markup.depth > 0 {
+			t.markup.depth -= 1
+		}
+	case ch == '(':
+		t.advance()
+		kind = token.InterpOpen
+		t.markup.mode = modeInterp
+		t.markup.parens = 0
+		t.markup.ret = modeMarkup
+	default:
+		t.text()
+		kind = token.Text
+	}
+
+	return t.markupToken(kind, offset)
+}
+
+func (t *Tokenizer) markupToken(kind token.Kind, offset int) token.Token {
+	t.markup.last = kind
+	text := string(t.src[offset:t.offset])
+	return token.NewWithText(kind, text, offset, t.offset)
+}
+
+// attr scans the next part of an attribute in an element head. It reports
+// false when what follows is the content of the element.
+func (t *Tokenizer) attr() (token.Kind, bool) {
+	switch last := t.markup.last; {
+	case last == token.Ident && t.ch == '=':
+		t.advance()
+		return token.Eq, true
+	case last == token.Eq && t.ch == '"':
+		t.advance()
+		t.string()
+		return token.String, true
+	case last == token.Eq && t.ch == '`':
+		t.advance()
+		t.rawString()
+		return token.String, true
+	case last == token.Eq && t.ch == '(':
+		t.advance()
+		t.markup.mode = modeInterp
+		t.markup.parens = 0
+		t.markup.ret = modeTag
+		return token.InterpOpen, true
+	case isLetter(t.ch) && t.isAttrKey():
+		t.ident()
+		return token.Ident, true
+	}
+	return token.Invalid, false
+}
+
+// isAttrKey reports whether the ident at the current offset is followed
+// by =.
+func (t *Tokenizer) isAttrKey() bool {
+	i := t.offset
+	for i < len(t.src) {
+		r, w := utf8.DecodeRune(t.src[i:])
+		if !isLetter(r) && !isDigit(r) {
+			break
+		}
+		i += w
+	}
+	for i < len(t.src) && token.IsSpace(rune(t.src[i])) {
+		i += 1
+	}
+	return i < len(t.src) && t.src[i] == '='
+}
+
+// skipMarkupSpace skips the space separating an element name, its
+// attributes and its content.
+func (t *Tokenizer) skipMarkupSpace() {
+	for token.IsSpace(t.ch) || t.ch == '\n' {
+		if t.ch == '\n' {
+			t.addLine(t.offset)
+		}
+		t.advance()
+	}
+}
+
+func (t *Tokenizer) text() {
+	for {
+		switch ch := t.ch; {
+		case ch == eof, ch == '}', ch == '(':
+			return
+		case ch == '<' && isLetter(t.peek()):
+			return
+		case ch == '/' && t.peek() == '>':
+			return
+		case ch == '\n':
+			t.addLine(t.offset)
+		}
+		t.advance()
+	}
+}
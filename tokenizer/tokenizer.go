@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"fmt"
 	"temlang/tem/token"
+	"unicode"
+	"unicode/utf8"
 )
 
 const (
 	eof = rune(-1)
+	bom = 0xFEFF // byte order mark, only allowed as the first char
 )
 
 func New(filename string, src []byte, opts ...Option) Tokenizer {
@@ -31,19 +34,22 @@ func New(filename string, src []byte, opts ...Option) Tokenizer {
 		tok.addLine(0)
 	}
 	tok.advance()
+	if tok.ch == bom {
+		tok.advance()
+	}
 	return tok
 }
 
 func isLetter(c rune) bool {
-	// TODO: handle other unicode letter
 	return (c >= 'A' && c <= 'Z') ||
 		(c >= 'a' && c <= 'z') ||
-		c == '_'
+		c == '_' ||
+		c >= utf8.RuneSelf && unicode.IsLetter(c)
 }
 
 func isDigit(c rune) bool {
-	// TODO: handle other unicode digit
-	return c >= '0' && c <= '9'
+	return c >= '0' && c <= '9' ||
+		c >= utf8.RuneSelf && unicode.IsDigit(c)
 }
 
 func DefaultErrorHandler(offset int, ch string, msg string) {
@@ -84,6 +90,7 @@ type Tokenizer struct {
 	errCount        int
 	semicolonFunc   SemicolonHandler
 	lines           []int
+	markup          markupState
 }
 
 func (t *Tokenizer) addLine(offset int) {
@@ -107,12 +114,16 @@ func (t *Tokenizer) Mark() func() {
 	prevOffset := t.offset
 	prevInsertSemicolon := t.insertSemicolon
 	prevRdOffset := t.rdOffset
+	prevLines := len(t.lines)
+	prevMarkup := t.markup
 
 	return func() {
 		t.ch = prev
 		t.offset = prevOffset
 		t.insertSemicolon = prevInsertSemicolon
 		t.rdOffset = prevRdOffset
+		t.lines = t.lines[:prevLines]
+		t.markup = prevMarkup
 	}
 }
 
@@ -137,9 +148,19 @@ func (t *Tokenizer) advance() {
 	}
 
 	offset := t.rdOffset
-	t.ch = rune(t.src[offset])
+	r, w := rune(t.src[offset]), 1
+	if r >= utf8.RuneSelf {
+		r, w = utf8.DecodeRune(t.src[offset:])
+		switch {
+		case r == utf8.RuneError && w == 1:
+			t.error(offset, string(t.src[offset:offset+1]), "Invalid UTF-8 encoding")
+		case r == bom && offset > 0:
+			t.error(offset, string(r), "Invalid BOM in the middle of the file")
+		}
+	}
+	t.ch = r
 	t.offset = offset
-	t.rdOffset += 1
+	t.rdOffset += w
 }
 
 func (t *Tokenizer) skipSpace() {
@@ -156,6 +177,10 @@ func (t *Tokenizer) Next() token.Token {
 	var kind token.Kind
 	var text string
 
+	if m := t.markup.mode; m == modeMarkup || m == modeTag {
+		return t.nextMarkup()
+	}
+
 	t.skipSpace()
 
 	insertSemiBeforeComment := false
@@ -174,6 +199,7 @@ semiColonInsertion:
 			fallthrough
 		case ch == eof:
 			t.insertSemicolon = false
+			t.markup.last = token.Semicolon
 			return token.New(token.Semicolon, offset, t.offset)
 		}
 	}
@@ -238,6 +264,10 @@ semiColonInsertion:
 		t.advance()
 		t.string()
 		kind = token.String
+	case '`':
+		t.advance()
+		t.rawString()
+		kind = token.String
 	case '/':
 		t.advance()
 		if t.ch != '/' {
@@ -266,10 +296,17 @@ semiColonInsertion:
 			break
 		}
 		kind = token.Invalid
-		t.error(offset, string(ch), "Invalid char")
+		// advance reports the invalid encodings and BOMs
+		invalidEncoding := ch == utf8.RuneError && t.offset-offset == 1
+		if ch != bom && !invalidEncoding {
+			t.error(offset, string(ch), "Invalid char")
+		}
 	}
 
-	t.semicolonFunc(t, kind)
+	kind = t.updateMarkup(kind)
+	if kind != token.EOL && kind != token.Comment {
+		t.markup.last = kind
+	}
 
 	text = string(t.src[offset:t.offset])
 	tok := token.NewWithText(kind, text, offset, t.offset)
@@ -300,6 +337,75 @@ func (t *Tokenizer) string() {
 		if ch == '"' {
 			break
 		}
+		if ch == '\\' {
+			t.escape()
+		}
+	}
+}
+
+// escape scans an escape sequence following a backslash in a string.
+func (t *Tokenizer) escape() {
+	offset := t.offset - 1
+	switch t.ch {
+	case '"', '\\', 'n', 'r', 't':
+		t.advance()
+		return
+	case 'u':
+		t.advance()
+	case '\n', eof:
+		// reported as an unterminated string
+		return
+	default:
+		t.error(offset, `\`+string(t.ch), "Unknown escape sequence")
+		return
+	}
+
+	var r rune
+	for range 4 {
+		d, ok := hexDigit(t.ch)
+		if !ok {
+			t.error(offset, string(t.src[offset:t.offset]), "Invalid unicode escape")
+			return
+		}
+		r = r*16 + d
+		t.advance()
+	}
+	if !utf8.ValidRune(r) {
+		t.error(offset, string(t.src[offset:t.offset]), "Escape is an invalid unicode code point")
+	}
+}
+
+func hexDigit(c rune) (rune, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// rawString scans a string between backticks. A raw string has no escape
+// sequences and can span several lines.
+func (t *Tokenizer) rawString() {
+	markerStart := t.offset - 1
+
+	for {
+		ch := t.ch
+		if ch <= eof {
+			str := string(t.src[markerStart:t.offset])
+			t.error(t.offset, str, "Unterminated raw string")
+			break
+		}
+		if ch == '\n' {
+			t.addLine(t.offset)
+		}
+		t.advance()
+		if ch == '`' {
+			break
+		}
 	}
 }
 
@@ -68,6 +68,17 @@ func TestNextIdent(t *testing.T) {
 		"foo": {tu.NewIdent(0, 3)},
 		"a12": {tu.NewIdent(0, 3)},
 		"_12": {tu.NewIdent(0, 3)},
+		"été": {tu.NewIdent(0, 5)},
+		"名前":  {tu.NewIdent(0, 6)},
+		"x١٢": {tu.NewIdent(0, 5)},
+	}
+	HelperRunTestCases(t, testcases, tokenizer.NoSemicolonInsertion())
+}
+
+func TestNextBOM(t *testing.T) {
+	testcases := TestCase{
+		"\uFEFFfoo":     {tu.NewIdent(3, 6)},
+		"\uFEFFé : été": {tu.NewIdent(3, 5), tu.NewSymbol(token.Colon, 6), tu.NewIdent(8, 13)},
 	}
 	HelperRunTestCases(t, testcases, tokenizer.NoSemicolonInsertion())
 }
@@ -87,9 +98,16 @@ func TestNextKeyword(t *testing.T) {
 
 func TestNextString(t *testing.T) {
 	testcases := TestCase{
-		`""`:    {tu.NewStr(0, 2)},
-		`"i"`:   {tu.NewStr(0, 3)},
-		`"foo"`: {tu.NewStr(0, 5)},
+		`""`:       {tu.NewStr(0, 2)},
+		`"i"`:      {tu.NewStr(0, 3)},
+		`"foo"`:    {tu.NewStr(0, 5)},
+		`"\""`:     {tu.NewStr(0, 4)},
+		`"a\\"`:    {tu.NewStr(0, 5)},
+		`"\n\t"`:   {tu.NewStr(0, 6)},
+		`"\u00e9"`: {tu.NewStr(0, 8)},
+		"`\\n`":    {tu.NewStr(0, 4)},
+		"`a\nb`":   {tu.NewStr(0, 5)},
+		"`\"`":     {tu.NewStr(0, 3)},
 	}
 	HelperRunTestCases(t, testcases, tokenizer.NoSemicolonInsertion())
 }
@@ -193,3 +211,73 @@ func TestNextNewline(t *testing.T) {
 		}
 	}
 }
+
+func TestNextMarkup(t *testing.T) {
+	testcases := TestCase{
+		`(){<p a="x" hi (u)/>}`: {
+			tu.NewSymbol(token.ParenOpen, 0),
+			tu.NewSymbol(token.ParenClose, 1),
+			tu.NewSymbol(token.BraceOpen, 2),
+			tu.NewToken(token.ElementOpen, 3, 5),
+			tu.NewIdent(6, 7),
+			tu.NewSymbol(token.Eq, 7),
+			tu.NewStr(8, 11),
+			tu.NewToken(token.Text, 12, 15),
+			tu.NewSymbol(token.InterpOpen, 15),
+			tu.NewIdent(16, 17),
+			tu.NewSymbol(token.InterpClose, 17),
+			tu.NewToken(token.SelfClose, 18, 20),
+			tu.NewSymbol(token.BraceClose, 20),
+			tu.NewEOL(21),
+		},
+		`(){<a href=(u.url) (f(x))/>}`: {
+			tu.NewSymbol(token.ParenOpen, 0),
+			tu.NewSymbol(token.ParenClose, 1),
+			tu.NewSymbol(token.BraceOpen, 2),
+			tu.NewToken(token.ElementOpen, 3, 5),
+			tu.NewIdent(6, 10),
+			tu.NewSymbol(token.Eq, 10),
+			tu.NewSymbol(token.InterpOpen, 11),
+			tu.NewIdent(12, 13),
+			tu.NewSymbol(token.Dot, 13),
+			tu.NewIdent(14, 17),
+			tu.NewSymbol(token.InterpClose, 17),
+			tu.NewSymbol(token.InterpOpen, 19),
+			tu.NewIdent(20, 21),
+			tu.NewSymbol(token.ParenOpen, 21),
+			tu.NewIdent(22, 23),
+			tu.NewSymbol(token.ParenClose, 23),
+			tu.NewSymbol(token.InterpClose, 24),
+			tu.NewToken(token.SelfClose, 25, 27),
+			tu.NewSymbol(token.BraceClose, 27),
+			tu.NewEOL(28),
+		},
+		`(){a < b / c}`: {
+			tu.NewSymbol(token.ParenOpen, 0),
+			tu.NewSymbol(token.ParenClose, 1),
+			tu.NewSymbol(token.BraceOpen, 2),
+			tu.NewToken(token.Text, 3, 12),
+			tu.NewSymbol(token.BraceClose, 12),
+			tu.NewEOL(13),
+		},
+	}
+	HelperRunTestCases(t, testcases)
+}
+
+func TestNextMarkupNoSemicolon(t *testing.T) {
+	testcases := TestCase{
+		"(){\n<p\nx\n/>\n}": {
+			tu.NewSymbol(token.ParenOpen, 0),
+			tu.NewSymbol(token.ParenClose, 1),
+			tu.NewSymbol(token.BraceOpen, 2),
+			tu.NewToken(token.Text, 3, 4),
+			tu.NewToken(token.ElementOpen, 4, 6),
+			tu.NewToken(token.Text, 7, 9),
+			tu.NewToken(token.SelfClose, 9, 11),
+			tu.NewToken(token.Text, 11, 12),
+			tu.NewSymbol(token.BraceClose, 12),
+			tu.NewEOL(13),
+		},
+	}
+	HelperRunTestCases(t, testcases)
+}
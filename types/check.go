@@ -0,0 +1,538 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"temlang/tem/ast"
+	"temlang/tem/escape"
+	"temlang/tem/resolver"
+	"temlang/tem/token"
+)
+
+// Info holds the types computed by the checker.
+type Info struct {
+	// Defs maps each declared name to the type of its declaration.
+	Defs map[*ast.Ident]Type
+	// Types maps the expressions of the templs to their type.
+	Types map[ast.Expr]Type
+}
+
+func (info *Info) TypeOf(e ast.Expr) Type {
+	if t, ok := info.Types[e]; ok {
+		return t
+	}
+	if ident, ok := e.(*ast.Ident); ok {
+		return info.Defs[ident]
+	}
+	return nil
+}
+
+// Check computes the type of every declaration of the namespace and
+// verifies that the declarations agree with the inference rules of the
+// language. The namespace is resolved first if it is not.
+func Check(ns *ast.Namespace, errors *token.ErrorQueue) *Info {
+	if ns.Scope() == nil {
+		resolver.Resolve(ns, errors)
+	}
+
+	c := checker{
+		ns:     ns,
+		errors: errors,
+		info: &Info{
+			Defs:  map[*ast.Ident]Type{},
+			Types: map[ast.Expr]Type{},
+		},
+		named:     map[*ast.Object]*Named{},
+		resolving: map[*ast.Object]bool{},
+	}
+
+	// the bodies of the templs may use the vars and the types declared
+	// after them, they are checked once every other declaration is
+	var templs []ast.Decl
+	for _, d := range ns.Decls() {
+		if _, ok := d.(*ast.TemplDecl); ok {
+			templs = append(templs, d)
+			continue
+		}
+		c.decl(d)
+	}
+	for _, d := range templs {
+		c.decl(d)
+	}
+	c.recursiveRecords()
+	return c.info
+}
+
+type checker struct {
+	ns        *ast.Namespace
+	errors    *token.ErrorQueue
+	info      *Info
+	named     map[*ast.Object]*Named
+	resolving map[*ast.Object]bool
+}
+
+func (c *checker) error(offset int, msg string) {
+	c.errors.Push(token.NewError(offset, msg).WithCode(token.CodeType))
+}
+
+func (c *checker) errorf(offset int, format string, args ...any) {
+	c.error(offset, fmt.Sprintf(format, args...))
+}
+
+func (c *checker) define(names []*ast.Ident, t Type) {
+	for _, name := range names {
+		c.info.Defs[name] = t
+	}
+}
+
+func (c *checker) decl(d ast.Decl) {
+	switch d := d.(type) {
+	case *ast.PackageDecl:
+		c.annotation(d.Type, d.Value, token.Package)
+		c.define(d.Names, c.pkg(d.Value))
+	case *ast.ImportDecl:
+		c.annotation(d.Type, d.Value, token.Import)
+		c.define(d.Names, c.pkg(d.Value))
+	case *ast.UsingDecl:
+		c.annotation(d.Type, d.Value, token.Using, token.Import)
+		for _, name := range d.Names {
+			c.info.Defs[name] = c.objType(name.Obj)
+		}
+	case *ast.TypeDecl:
+		c.annotation(d.Type, d.Value, token.Type)
+		for _, name := range d.Names {
+			c.info.Defs[name] = c.objType(name.Obj)
+		}
+	case *ast.TemplDecl:
+		if d.Type != nil && d.Type.Name == "type" {
+			c.error(d.Type.Start, "type cannot be inferred for a templ declaration")
+		} else {
+			c.annotation(d.Type, d.Value, token.Templ)
+		}
+		c.templ(d)
+	case *ast.Field:
+		c.define(d.Names, c.typeOf(d.Type))
+	}
+}
+
+// annotation checks that the explicit type annotation of a declaration
+// is one of the keywords allowed for its expression.
+func (c *checker) annotation(annot *ast.Ident, e ast.Expr, allowed ...token.Kind) {
+	if annot == nil {
+		return
+	}
+	kind, ok := token.KeywordKind(annot.Name)
+	if ok {
+		for _, k := range allowed {
+			if k == kind {
+				return
+			}
+		}
+	}
+	c.errorf(annot.Start, "mismatched type annotation: %s declared as %s", exprKind(e), annot.Name)
+}
+
+func exprKind(e ast.Expr) string {
+	switch e.(type) {
+	case *ast.PackageExpr:
+		return "package"
+	case *ast.ImportExpr:
+		return "import"
+	case *ast.UsingExpr:
+		return "using"
+	case *ast.TypeExpr:
+		return "type"
+	case *ast.RecordType:
+		return "record"
+	case *ast.TemplLit:
+		return "templ"
+	default:
+		return "expression"
+	}
+}
+
+func (c *checker) pkg(e ast.Expr) Type {
+	var lit *ast.BasicLit
+	switch e := e.(type) {
+	case *ast.PackageExpr:
+		lit = e.Name
+	case *ast.ImportExpr:
+		lit = e.Path
+	default:
+		return Typ[Invalid]
+	}
+	path, err := token.Unquote(lit.Value)
+	if err != nil {
+		path = lit.Value
+	}
+	return NewPackage(path)
+}
+
+// objType returns the type denoted by a resolved object.
+func (c *checker) objType(obj *ast.Object) Type {
+	if obj == nil {
+		return Typ[Invalid]
+	}
+	switch obj.Kind {
+	case ast.Builtin:
+		return universe[obj.Name]
+	case ast.Helper:
+		return helpers[obj.Name]
+	case ast.Typ:
+		return c.namedType(obj)
+	default:
+		return Typ[Invalid]
+	}
+}
+
+func (c *checker) namedType(obj *ast.Object) Type {
+	if n, ok := c.named[obj]; ok {
+		if c.resolving[obj] {
+			c.errorf(obj.Decl.Pos().Start, "invalid recursive type %s", obj.Name)
+			n.SetUnderlying(Typ[Invalid])
+			c.resolving[obj] = false
+		}
+		return n
+	}
+
+	n := NewNamed(obj, nil)
+	c.named[obj] = n
+
+	d, ok := obj.Decl.(*ast.TypeDecl)
+	if !ok {
+		// the names declared by using denote a type of another namespace
+		// once the namespaces are linked
+		if obj.Target != nil {
+			n.SetUnderlying(c.objType(obj.Target).Underlying())
+		} else {
+			n.SetUnderlying(Typ[Invalid])
+		}
+		return n
+	}
+
+	switch e := d.Value.(type) {
+	case *ast.TypeExpr:
+		c.resolving[obj] = true
+		t := c.typeOf(e.Target)
+		if c.resolving[obj] {
+			n.SetUnderlying(t.Underlying())
+		}
+		c.resolving[obj] = false
+	case *ast.RecordType:
+		n.SetUnderlying(c.record(e))
+	default:
+		n.SetUnderlying(Typ[Invalid])
+	}
+	return n
+}
+
+func (c *checker) typeOf(e ast.Expr) Type {
+	if list, ok := e.(*ast.ListType); ok {
+		return NewList(c.typeOf(list.Elem))
+	}
+	ident, ok := e.(*ast.Ident)
+	if !ok {
+		return Typ[Invalid]
+	}
+	return c.objType(ident.Obj)
+}
+
+func (c *checker) record(e *ast.RecordType) *Record {
+	var fields []*Var
+	for _, d := range e.Fields {
+		field, ok := d.(*ast.Field)
+		if !ok {
+			continue
+		}
+		t := c.typeOf(field.Type)
+		for _, name := range field.Names {
+			fields = append(fields, NewVar(name.Name, t))
+			c.info.Defs[name] = t
+		}
+	}
+	return NewRecord(fields)
+}
+
+// recursiveRecords reports the records containing themselves.
+func (c *checker) recursiveRecords() {
+	for _, d := range c.ns.Decls() {
+		d, ok := d.(*ast.TypeDecl)
+		if !ok {
+			continue
+		}
+		for _, name := range d.Names {
+			n, ok := c.named[name.Obj]
+			if ok && contains(n.Underlying(), n, map[Type]bool{}) {
+				c.errorf(name.Start, "invalid recursive type %s", name.Name)
+			}
+		}
+	}
+}
+
+func contains(t Type, n *Named, seen map[Type]bool) bool {
+	r, ok := t.(*Record)
+	if !ok || seen[r] {
+		return false
+	}
+	seen[r] = true
+	for _, f := range r.fields {
+		if f.typ == n || contains(f.typ.Underlying(), n, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *checker) templ(d *ast.TemplDecl) {
+	lit, ok := d.Value.(*ast.TemplLit)
+	if !ok {
+		c.define(d.Names, Typ[Invalid])
+		return
+	}
+
+	var params []*ast.Ident
+	var param *Var
+	for _, p := range lit.Params {
+		t := c.typeOf(p.Type)
+		for _, name := range p.Names {
+			params = append(params, name)
+			param = NewVar(name.Name, t)
+			c.info.Defs[name] = t
+		}
+	}
+
+	if len(params) != 1 {
+		c.errorf(lit.Start, "templ must have exactly one parameter, got %d", len(params))
+	}
+
+	templ := NewTempl(param)
+	c.define(d.Names, templ)
+
+	// the templ renders the type with the same name
+	if param != nil {
+		for _, name := range d.Names {
+			obj := c.ns.Scope().LookupParent(name.Name)
+			if obj == nil || (obj.Kind != ast.Typ && obj.Kind != ast.Builtin) {
+				c.errorf(name.Start, "templ %s does not name a type", name.Name)
+				continue
+			}
+			if t := c.objType(obj); t != param.typ && param.typ != Typ[Invalid] {
+				c.errorf(lit.Params[0].Start, "templ parameter type %s does not match %s", param.typ, t)
+			}
+		}
+	}
+
+	for _, m := range lit.Body {
+		c.markup(m, escape.Text)
+	}
+}
+
+// markup checks the interpolations of m, ctx is the context of the
+// content of the enclosing element. The elements in a script or a style
+// are text of the script or the style for the browser.
+func (c *checker) markup(m ast.Markup, ctx escape.Context) {
+	switch m := m.(type) {
+	case *ast.Element:
+		if escape.Void(m.Name.Name) && len(m.Children) != 0 {
+			c.errorf(m.Start, "void element %s cannot have children", m.Name.Name)
+		}
+		for _, attr := range m.Attrs {
+			if _, ok := attr.Value.(*ast.BasicLit); ok {
+				continue
+			}
+			// the value is interpolated for each name
+			var ctxs []escape.Context
+			for _, key := range attr.Names {
+				ctxs = append(ctxs, escape.In(ctx, m.Name.Name, key.Name))
+			}
+			c.interp(attr.Value, ctxs...)
+		}
+		for _, child := range m.Children {
+			c.markup(child, escape.In(ctx, m.Name.Name, ""))
+		}
+	case *ast.Interp:
+		c.interp(m.X, ctx)
+	case *ast.IfElement:
+		c.cond(m.Cond)
+		for _, child := range m.Children {
+			c.markup(child, ctx)
+		}
+		if m.Else != nil {
+			for _, child := range m.Else.Children {
+				c.markup(child, ctx)
+			}
+		}
+	case *ast.ForElement:
+		c.define(forNames(m), Typ[Invalid])
+		t := c.expr(m.X)
+		if list, ok := t.Underlying().(*List); ok {
+			c.info.Defs[m.Value] = list.elem
+			if m.Index != nil {
+				c.info.Defs[m.Index] = Typ[Int]
+			}
+		} else if t != Typ[Invalid] && t.Underlying() != Typ[Invalid] {
+			c.errorf(m.X.Pos().Start, "cannot range over %s of type %s", exprString(m.X), t)
+		}
+		for _, child := range m.Children {
+			c.markup(child, ctx)
+		}
+	}
+}
+
+// cond checks that the condition of an if element is a Bool.
+func (c *checker) cond(e ast.Expr) {
+	t := c.expr(e)
+	if t == Typ[Invalid] || t.Underlying() == Typ[Invalid] {
+		return
+	}
+	if b, ok := t.Underlying().(*Basic); !ok || b.Kind() != Bool {
+		c.errorf(e.Pos().Start, "non-Bool condition %s of type %s in if", exprString(e), t)
+	}
+}
+
+// forNames returns the names declared by a for element.
+func forNames(m *ast.ForElement) []*ast.Ident {
+	if m.Index == nil {
+		return []*ast.Ident{m.Value}
+	}
+	return []*ast.Ident{m.Index, m.Value}
+}
+
+// interp checks that the value of an interpolation can be rendered and
+// escaped for its contexts. A string can't be escaped in a script or a
+// style, it must be trusted HTML.
+func (c *checker) interp(e ast.Expr, ctxs ...escape.Context) {
+	t := c.expr(e)
+	b, ok := t.Underlying().(*Basic)
+	if !ok {
+		c.errorf(e.Pos().Start, "cannot interpolate a value of type %s", t)
+		return
+	}
+	for _, ctx := range ctxs {
+		if b.Kind() == String && !ctx.Safe() {
+			c.errorf(e.Pos().Start, "cannot interpolate a String in a %s, only HTML is trusted there", ctx)
+			return
+		}
+	}
+}
+
+func (c *checker) expr(e ast.Expr) Type {
+	t := c.exprInternal(e)
+	c.info.Types[e] = t
+	return t
+}
+
+func (c *checker) exprInternal(e ast.Expr) Type {
+	switch e := e.(type) {
+	case *ast.Ident:
+		if e.Obj != nil && e.Obj.Kind == ast.Helper {
+			c.errorf(e.Start, "helper %s must be called", e.Name)
+			return Typ[Invalid]
+		}
+		if e.Obj == nil || e.Obj.Kind != ast.Var {
+			return Typ[Invalid]
+		}
+		t, ok := c.info.Defs[identOf(e.Obj)]
+		if !ok || t == nil {
+			c.errorf(e.Start, "type of %s is not known", e.Name)
+			return Typ[Invalid]
+		}
+		return t
+	case *ast.BasicLit:
+		if e.Kind != token.String {
+			return Typ[Invalid]
+		}
+		return Typ[String]
+	case *ast.ParenExpr:
+		return c.expr(e.X)
+	case *ast.CallExpr:
+		return c.call(e)
+	case *ast.SelectorExpr:
+		x := c.expr(e.X)
+		if x == Typ[Invalid] || x.Underlying() == Typ[Invalid] {
+			return Typ[Invalid]
+		}
+		r, ok := x.Underlying().(*Record)
+		if !ok {
+			c.errorf(e.Sel.Start, "%s has no field %s", x, e.Sel.Name)
+			return Typ[Invalid]
+		}
+		f := r.Field(e.Sel.Name)
+		if f == nil {
+			c.errorf(e.Sel.Start, "%s has no field %s", x, e.Sel.Name)
+			return Typ[Invalid]
+		}
+		return f.typ
+	default:
+		return Typ[Invalid]
+	}
+}
+
+// call checks the arguments of a call to a helper and returns its result
+// type.
+func (c *checker) call(e *ast.CallExpr) Type {
+	fun, ok := e.Fun.(*ast.Ident)
+	if !ok || fun.Obj == nil || fun.Obj.Kind != ast.Helper {
+		if !ok || fun.Obj != nil {
+			c.errorf(e.Fun.Pos().Start, "cannot call %s, it is not a helper", exprString(e.Fun))
+		}
+		for _, arg := range e.Args {
+			c.expr(arg)
+		}
+		return Typ[Invalid]
+	}
+	h := helpers[fun.Name]
+	c.info.Types[fun] = h
+
+	if len(e.Args) != len(h.params) {
+		c.errorf(e.Start, "wrong number of arguments to %s: expected %d got %d", fun.Name, len(h.params), len(e.Args))
+	}
+	for i, arg := range e.Args {
+		t := c.expr(arg)
+		if i >= len(h.params) || t == Typ[Invalid] || t.Underlying() == Typ[Invalid] {
+			continue
+		}
+		if t.Underlying() != h.params[i] {
+			c.errorf(arg.Pos().Start, "cannot use %s of type %s as %s in argument to %s", exprString(arg), t, h.params[i], fun.Name)
+		}
+	}
+	return h.result
+}
+
+// exprString returns the source of an expression of an interpolation.
+func exprString(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.BasicLit:
+		return e.Value
+	case *ast.SelectorExpr:
+		return exprString(e.X) + "." + e.Sel.Name
+	case *ast.ParenExpr:
+		return "(" + exprString(e.X) + ")"
+	case *ast.CallExpr:
+		args := make([]string, 0, len(e.Args))
+		for _, arg := range e.Args {
+			args = append(args, exprString(arg))
+		}
+		return exprString(e.Fun) + "(" + strings.Join(args, ", ") + ")"
+	}
+	return "expression"
+}
+
+// identOf returns the ident declaring obj.
+func identOf(obj *ast.Object) *ast.Ident {
+	var names []*ast.Ident
+	switch d := obj.Decl.(type) {
+	case *ast.Field:
+		names = d.Names
+	case *ast.ForElement:
+		names = forNames(d)
+	}
+	for _, name := range names {
+		if name.Obj == obj {
+			return name
+		}
+	}
+	return nil
+}
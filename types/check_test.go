@@ -0,0 +1,248 @@
+package types_test
+
+import (
+	"fmt"
+	"temlang/tem/ast"
+	"temlang/tem/parser"
+	"temlang/tem/resolver"
+	"temlang/tem/token"
+	"temlang/tem/types"
+	"testing"
+)
+
+func check(t *testing.T, src string) (*ast.Namespace, *types.Info, *token.ErrorQueue) {
+	t.Helper()
+	file, errs := parser.ParseFile("test.tem", []byte(src))
+	if errs.Len() != 0 {
+		t.Fatalf("ParseFile(%s) failed unexpectedly", src)
+	}
+	info := types.Check(file, errs)
+	return file, info, errs
+}
+
+var valids = []string{
+	`p :: package("m");   t :: type(String)`,
+	`p :: package("m");   t : type : type(String)`,
+	`p :: package("m");   t : type : record{ a: String }`,
+	`p :: package("m");   t :: type(r); r :: record{ a: String }`,
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <p (v.a)/> }`,
+	`p :: package("m");   t :: record{ a: String }; t : templ : templ(v: type){ <p (v.a)/> }`,
+	`p :: package("m");   t :: record{ a: r }; r :: record{ b: Int }; t :: templ(v: t){ <p (v.a.b)/> }`,
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <a href=(v.a)/> }`,
+	`p :: package("m");   t :: type(String); t :: templ(v: t){ <p (v)/> }`,
+	`p :: package("m");   String :: templ(s: String){ <p (s)/> }`,
+	`p :: package("m");   t :: record{ a: HTML }; t :: templ(v: t){ <script (v.a)/> }`,
+	`p :: package("m");   t :: record{ a: HTML }; t :: templ(v: t){ <p style=(v.a)/> }`,
+	`p :: package("m");   t :: record{ a: Int }; t :: templ(v: t){ <p onclick=(v.a)/> }`,
+	// declared after the templ
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <p (x)/> }; x : String`,
+	`p :: package("m");   t :: templ(v: t){ <p (v.a)/> }; t :: record{ a: r }; r :: type(String)`,
+	// helpers
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <p (upper(v.a)) ("-") (lower(trim(("x"))))/> }`,
+	`p :: package("m");   t :: type(String); t :: templ(v: t){ <a href=(lower(v))/> }`,
+	// control flow
+	`p :: package("m");   t :: record{ a: Bool }; t :: templ(v: t){ <if (v.a) <p/>/><else <br/>/> }`,
+	`p :: package("m");   t :: record{ a: []String }; t :: templ(v: t){ <for (i, s in v.a) <p (i) (upper(s))/>/> }`,
+	`p :: package("m");   t :: record{ a: []r }; r :: record{ b: []String }; t :: templ(v: t){ <for (r in v.a) <for (s in r.b) (s)/>/> }`,
+	`p :: package("m");   t :: record{ a: []t; b: Bool }; t :: templ(v: t){ <for (c in v.a) <if (c.b) (v.b)/>/> }`,
+}
+
+func TestCheckValids(t *testing.T) {
+	for i, src := range valids {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			_, _, errs := check(t, src)
+			for !errs.Empty() {
+				err, _ := errs.Pop()
+				t.Error(err)
+			}
+		})
+	}
+}
+
+var invalids = []string{
+	// annotation
+	`p :: package("m");   t : templ : record{}`,
+	`p :: package("m");   t : import : type(String)`,
+	`p :: package("m");   t :: record{}; t : type : templ(v: t){}`,
+	// recursive
+	`p :: package("m");   a :: type(b); b :: type(a)`,
+	`p :: package("m");   a :: record{ a: a }`,
+	`p :: package("m");   a :: record{ b: b }; b :: record{ a: a }`,
+	// templ
+	`p :: package("m");   t :: record{}; t :: templ(v: t, w: t){}`,
+	`p :: package("m");   t :: record{}; r :: record{}; t :: templ(v: r){}`,
+	`p :: package("m");   r :: record{}; t :: templ(v: r){}`,
+	// interpolation
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <p (v.b)/> }`,
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <p (v.a.b)/> }`,
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <p (v)/> }`,
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <a href=(v)/> }`,
+	// unsafe context
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <script (v.a)/> }`,
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <style <p (v.a)/>/> }`,
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <p onclick=(v.a)/> }`,
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <script <p title=(v.a)/>/> }`,
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <script (upper(v.a))/> }`,
+	// void element
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <br (v.a)/> }`,
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <IMG <p/>/> }`,
+	// helpers
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <p (upper)/> }`,
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <p (upper(v.a, v.a))/> }`,
+	`p :: package("m");   t :: record{ a: Int }; t :: templ(v: t){ <p (upper(v.a))/> }`,
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <p (v.a(v.a))/> }`,
+	`p :: package("m");   t :: record{ a: upper }; t :: templ(v: t){ <p (v.a)/> }`,
+	// control flow
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <if (v.a)/> }`,
+	`p :: package("m");   t :: record{ a: String }; t :: templ(v: t){ <for (s in v.a)/> }`,
+	`p :: package("m");   t :: record{ a: []String }; t :: templ(v: t){ <for (s in v.a)/> (s) }`,
+	`p :: package("m");   t :: record{ a: []String }; t :: templ(v: t){ <for (s in v.a) (s.b)/> }`,
+	`p :: package("m");   t :: record{ a: []String }; t :: templ(v: t){ <for (s, s in v.a)/> }`,
+	`p :: package("m");   t :: record{ a: []String }; t :: templ(v: t){ <p (v.a)/> }`,
+	`p :: package("m");   t :: record{ a: []String }; t :: templ(v: t){ <script <for (s in v.a) (s)/>/> }`,
+}
+
+func TestCheckInvalids(t *testing.T) {
+	for i, src := range invalids {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			_, _, errs := check(t, src)
+			if errs.Len() == 0 {
+				t.Errorf("Check(%s) succeeded unexpectedly", src)
+			}
+		})
+	}
+}
+
+func TestCheckInfo(t *testing.T) {
+	src := `
+		p :: package("m")
+		Person :: record{ name: String; age: Int }
+		User :: type(Person)
+		User :: templ(u: type){ <p (u.name)/> }
+	`
+	file, info, errs := check(t, src)
+	if errs.Len() != 0 {
+		t.Fatal("Check failed unexpectedly")
+	}
+
+	decls := file.Decls()
+	person := decls[1].(*ast.TypeDecl)
+	user := decls[2].(*ast.TypeDecl)
+	templ := decls[3].(*ast.TemplDecl)
+
+	personType, ok := info.Defs[person.Names[0]].(*types.Named)
+	if !ok {
+		t.Fatalf("expected Person to be a named type got %v", info.Defs[person.Names[0]])
+	}
+	record, ok := personType.Underlying().(*types.Record)
+	if !ok {
+		t.Fatalf("expected Person to be a record got %v", personType.Underlying())
+	}
+	if f := record.Field("age"); f == nil || f.Type() != types.Typ[types.Int] {
+		t.Errorf("expected the field age of type Int got %v", f)
+	}
+
+	userType := info.Defs[user.Names[0]]
+	if userType.Underlying() != record {
+		t.Errorf("expected User to have the underlying type of Person got %v", userType.Underlying())
+	}
+
+	templType, ok := info.Defs[templ.Names[0]].(*types.Templ)
+	if !ok {
+		t.Fatalf("expected User to be a templ got %v", info.Defs[templ.Names[0]])
+	}
+	if p := templType.Param(); p == nil || p.Type() != userType {
+		t.Errorf("expected the templ parameter to be a User got %v", p)
+	}
+
+	interp := templ.Value.(*ast.TemplLit).Body[0].(*ast.Element).Children[0].(*ast.Interp)
+	if typ := info.TypeOf(interp.X); typ != types.Typ[types.String] {
+		t.Errorf("expected u.name to be a String got %v", typ)
+	}
+}
+
+func TestCheckUsingTarget(t *testing.T) {
+	book, _, errs := check(t, `p :: package("books"); Book :: record{ title: String }`)
+	if !errs.Empty() {
+		t.Fatal("check of books failed unexpectedly")
+	}
+
+	src := `p :: package("m"); b :: import("books"); Book :: using(b); Book :: templ(v: Book){ <p (v.title)/> }`
+	file, errs := parser.ParseFile("test.tem", []byte(src))
+	if errs.Len() != 0 {
+		t.Fatalf("ParseFile(%s) failed unexpectedly", src)
+	}
+	resolver.Resolve(file, errs)
+	// link the name declared by using like the loader does
+	using := file.Decls()[2].(*ast.UsingDecl).Names[0]
+	using.Obj.Target = book.Scope().Lookup("Book")
+
+	types.Check(file, errs)
+	for !errs.Empty() {
+		err, _ := errs.Pop()
+		t.Error(err)
+	}
+}
+
+func TestCheckHelpers(t *testing.T) {
+	testcases := []struct {
+		markup string
+		err    string
+	}{
+		{`(upper)`, "helper upper must be called"},
+		{`(upper(u.name, u.name))`, "wrong number of arguments to upper: expected 1 got 2"},
+		{`(upper(u.age))`, "cannot use u.age of type Int as String in argument to upper"},
+		{`(u.name(u.name))`, "cannot call u.name, it is not a helper"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.markup, func(t *testing.T) {
+			src := `p :: package("m"); u :: record{ name: String; age: Int }; u :: templ(u: u){ <p ` + tc.markup + `/> }`
+			_, _, errs := check(t, src)
+			err, ok := errs.Pop()
+			if !ok || err.Message() != tc.err {
+				t.Errorf("expected %q got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestCheckControlFlow(t *testing.T) {
+	testcases := []struct {
+		markup string
+		err    string
+	}{
+		{`<if (u.name)/>`, "non-Bool condition u.name of type String in if"},
+		{`<for (c in u.name)/>`, "cannot range over u.name of type String"},
+		{`<for (c in u.friends) (c)/>`, "cannot interpolate a value of type u"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.markup, func(t *testing.T) {
+			src := `p :: package("m"); u :: record{ name: String; friends: []u }; u :: templ(u: u){ ` + tc.markup + ` }`
+			_, _, errs := check(t, src)
+			err, ok := errs.Pop()
+			if !ok || err.Message() != tc.err {
+				t.Errorf("expected %q got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestCheckForInfo(t *testing.T) {
+	src := `p :: package("m"); u :: record{ friends: []u }; u :: templ(u: u){ <for (i, f in u.friends) (i)/> }`
+	file, info, errs := check(t, src)
+	if !errs.Empty() {
+		err, _ := errs.Pop()
+		t.Fatal(err)
+	}
+	templ := file.Decls()[2].(*ast.TemplDecl)
+	loop := templ.Value.(*ast.TemplLit).Body[0].(*ast.ForElement)
+	if typ := info.TypeOf(loop.X); typ.String() != "[]u" {
+		t.Errorf("expected u.friends to be a []u got %v", typ)
+	}
+	if typ := info.TypeOf(loop.Value); typ.String() != "u" {
+		t.Errorf("expected f to be a u got %v", typ)
+	}
+	if typ := info.TypeOf(loop.Index); typ != types.Typ[types.Int] {
+		t.Errorf("expected i to be an Int got %v", typ)
+	}
+}
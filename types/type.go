@@ -0,0 +1,242 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"temlang/tem/ast"
+)
+
+type Type interface {
+	Underlying() Type
+	String() string
+}
+
+type BasicKind int
+
+const (
+	Invalid BasicKind = iota
+	Bool
+	Float
+	Int
+	String
+	// HTML is a string trusted as HTML, it is written without escaping
+	HTML
+)
+
+// Basic is a builtin type. The invalid type is the type of the
+// expressions with an error.
+type Basic struct {
+	kind BasicKind
+	name string
+}
+
+func (b *Basic) Kind() BasicKind {
+	return b.kind
+}
+
+func (b *Basic) Name() string {
+	return b.name
+}
+
+func (b *Basic) Underlying() Type {
+	return b
+}
+
+func (b *Basic) String() string {
+	return b.name
+}
+
+var Typ = []*Basic{
+	Invalid: {Invalid, "invalid type"},
+	Bool:    {Bool, "Bool"},
+	Float:   {Float, "Float"},
+	Int:     {Int, "Int"},
+	String:  {String, "String"},
+	HTML:    {HTML, "HTML"},
+}
+
+// Named is a type declared by a type declaration or imported by a using
+// declaration.
+type Named struct {
+	obj        *ast.Object
+	underlying Type
+}
+
+func NewNamed(obj *ast.Object, underlying Type) *Named {
+	return &Named{obj: obj, underlying: underlying}
+}
+
+func (n *Named) Obj() *ast.Object {
+	return n.obj
+}
+
+func (n *Named) SetUnderlying(t Type) {
+	n.underlying = t
+}
+
+func (n *Named) Underlying() Type {
+	if n.underlying == nil {
+		return Typ[Invalid]
+	}
+	return n.underlying
+}
+
+func (n *Named) String() string {
+	return n.obj.Name
+}
+
+type Var struct {
+	name string
+	typ  Type
+}
+
+func NewVar(name string, typ Type) *Var {
+	return &Var{name: name, typ: typ}
+}
+
+func (v *Var) Name() string {
+	return v.name
+}
+
+func (v *Var) Type() Type {
+	return v.typ
+}
+
+func (v *Var) String() string {
+	return fmt.Sprintf("%s: %s", v.name, v.typ)
+}
+
+type Record struct {
+	fields []*Var
+}
+
+func NewRecord(fields []*Var) *Record {
+	return &Record{fields: fields}
+}
+
+func (r *Record) Fields() []*Var {
+	return r.fields
+}
+
+// Field returns the field with the given name or nil.
+func (r *Record) Field(name string) *Var {
+	for _, f := range r.fields {
+		if f.name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func (r *Record) Underlying() Type {
+	return r
+}
+
+func (r *Record) String() string {
+	fields := make([]string, 0, len(r.fields))
+	for _, f := range r.fields {
+		fields = append(fields, f.String())
+	}
+	return fmt.Sprintf("record{%s}", strings.Join(fields, "; "))
+}
+
+// List is the type of a list of values of the element type.
+type List struct {
+	elem Type
+}
+
+func NewList(elem Type) *List {
+	return &List{elem: elem}
+}
+
+func (l *List) Elem() Type {
+	return l.elem
+}
+
+func (l *List) Underlying() Type {
+	return l
+}
+
+func (l *List) String() string {
+	return "[]" + l.elem.String()
+}
+
+// Templ is the type of a template rendering its param.
+type Templ struct {
+	param *Var
+}
+
+func NewTempl(param *Var) *Templ {
+	return &Templ{param: param}
+}
+
+func (t *Templ) Param() *Var {
+	return t.param
+}
+
+func (t *Templ) Underlying() Type {
+	return t
+}
+
+func (t *Templ) String() string {
+	if t.param == nil {
+		return "templ()"
+	}
+	return fmt.Sprintf("templ(%s)", t.param)
+}
+
+// Helper is the type of a builtin helper called in the interpolations.
+type Helper struct {
+	name   string
+	params []Type
+	result Type
+}
+
+func NewHelper(name string, params []Type, result Type) *Helper {
+	return &Helper{name: name, params: params, result: result}
+}
+
+func (h *Helper) Name() string {
+	return h.name
+}
+
+func (h *Helper) Params() []Type {
+	return h.params
+}
+
+func (h *Helper) Result() Type {
+	return h.result
+}
+
+func (h *Helper) Underlying() Type {
+	return h
+}
+
+func (h *Helper) String() string {
+	params := make([]string, 0, len(h.params))
+	for _, p := range h.params {
+		params = append(params, p.String())
+	}
+	return fmt.Sprintf("%s(%s) %s", h.name, strings.Join(params, ", "), h.result)
+}
+
+// Package is the type of a package or import declaration.
+type Package struct {
+	path string
+}
+
+func NewPackage(path string) *Package {
+	return &Package{path: path}
+}
+
+func (p *Package) Path() string {
+	return p.path
+}
+
+func (p *Package) Underlying() Type {
+	return p
+}
+
+func (p *Package) String() string {
+	return fmt.Sprintf("package(%q)", p.path)
+}
@@ -0,0 +1,17 @@
+package types
+
+// universe maps the builtin types of the resolver universe to their type.
+var universe = map[string]Type{
+	"Bool":   Typ[Bool],
+	"Float":  Typ[Float],
+	"HTML":   Typ[HTML],
+	"Int":    Typ[Int],
+	"String": Typ[String],
+}
+
+// helpers maps the helpers of the resolver universe to their type.
+var helpers = map[string]*Helper{
+	"lower": NewHelper("lower", []Type{Typ[String]}, Typ[String]),
+	"trim":  NewHelper("trim", []Type{Typ[String]}, Typ[String]),
+	"upper": NewHelper("upper", []Type{Typ[String]}, Typ[String]),
+}